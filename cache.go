@@ -0,0 +1,55 @@
+package unutf16
+
+import (
+	"io"
+	"sync"
+)
+
+// DetectorCache memoizes the encodings Detect reports, for servers that repeatedly
+// decode the same small set of sources, such as templates. The zero value is an empty
+// cache ready to use, and it is safe for concurrent use by multiple goroutines.
+//
+// The cache does not trust the key alone to identify the content: every call still
+// peeks the BOM of its source and checks it against the remembered encoding, so a key
+// whose content changed is detected again rather than reported stale. Keys that change
+// with the content, e.g. a hash of it, keep the remembered encodings from churning.
+type DetectorCache struct {
+	mu        sync.RWMutex
+	encodings map[string]Encoding
+}
+
+// Detect reports the encoding of r like the package-level Detect, and returns an
+// io.Reader that yields the complete stream of r, including the BOM. Each call peeks
+// the BOM of r; if it no longer indicates the encoding remembered for key, the stale
+// entry is evicted and the encoding of r is remembered instead. A peek failure evicts
+// the key and is not remembered.
+func (c *DetectorCache) Detect(key string, r io.Reader) (Encoding, io.Reader, error) {
+	encoding, stitched, err := Detect(r)
+	if err != nil {
+		c.Forget(key)
+		return EncodingUnknown, nil, err
+	}
+
+	c.mu.RLock()
+	remembered, ok := c.encodings[key]
+	c.mu.RUnlock()
+	if ok && remembered == encoding {
+		return remembered, stitched, nil
+	}
+
+	c.mu.Lock()
+	if c.encodings == nil {
+		c.encodings = make(map[string]Encoding)
+	}
+	c.encodings[key] = encoding
+	c.mu.Unlock()
+	return encoding, stitched, nil
+}
+
+// Forget removes the encoding remembered for key, if any, so that the next call to
+// Detect for key inspects its source again.
+func (c *DetectorCache) Forget(key string) {
+	c.mu.Lock()
+	delete(c.encodings, key)
+	c.mu.Unlock()
+}
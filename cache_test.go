@@ -0,0 +1,117 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestDetectorCache tests that the cache remembers the encoding per key and replays the complete stream
+func TestDetectorCache(t *testing.T) {
+	// UTF-16LE data (BOM + "hi")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}
+
+	var cache unutf16.DetectorCache
+	for i := 0; i < 2; i++ {
+		encoding, reader, err := cache.Detect("template", bytes.NewReader(utf16leData))
+		if err != nil {
+			t.Fatalf("Error detecting encoding: %v", err)
+		}
+
+		output, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Error reading from detected reader: %v", err)
+		}
+
+		assert.Equal(t, unutf16.EncodingUTF16LE, encoding)
+		assert.Equal(t, utf16leData, output)
+	}
+
+	// Other keys are detected on their own
+	encoding, _, err := cache.Detect("other", bytes.NewReader([]byte("hi")))
+	assert.NoError(t, err)
+	assert.Equal(t, unutf16.EncodingPassthrough, encoding)
+}
+
+// TestDetectorCacheChangedContent tests that a key whose content changed is detected again
+func TestDetectorCacheChangedContent(t *testing.T) {
+	var cache unutf16.DetectorCache
+	tests := []struct {
+		name     string
+		input    []byte
+		expected unutf16.Encoding
+	}{
+		{"UTF-16LE", []byte{0xFF, 0xFE, 0x68, 0x00}, unutf16.EncodingUTF16LE},
+		{"UTF-16BE", []byte{0xFE, 0xFF, 0x00, 0x68}, unutf16.EncodingUTF16BE},
+		{"no BOM", []byte("hi"), unutf16.EncodingPassthrough},
+		{"UTF-16LE again", []byte{0xFF, 0xFE, 0x68, 0x00}, unutf16.EncodingUTF16LE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, reader, err := cache.Detect("template", bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Error detecting encoding: %v", err)
+			}
+
+			output, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Error reading from detected reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, encoding)
+			assert.Equal(t, tt.input, output)
+		})
+	}
+}
+
+// TestDetectorCacheForget tests that a forgotten key is detected again
+func TestDetectorCacheForget(t *testing.T) {
+	var cache unutf16.DetectorCache
+	_, _, err := cache.Detect("template", bytes.NewReader([]byte{0xFE, 0xFF, 0x00, 0x68}))
+	if err != nil {
+		t.Fatalf("Error detecting encoding: %v", err)
+	}
+
+	cache.Forget("template")
+	encoding, _, err := cache.Detect("template", bytes.NewReader([]byte("hi")))
+	assert.NoError(t, err)
+	assert.Equal(t, unutf16.EncodingPassthrough, encoding)
+}
+
+// TestDetectorCachePeekFailure tests that peek failures are reported, not remembered and evict the key
+func TestDetectorCachePeekFailure(t *testing.T) {
+	var cache unutf16.DetectorCache
+	_, _, err := cache.Detect("template", new(errorReader))
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+
+	encoding, _, err := cache.Detect("template", bytes.NewReader([]byte{0xFF, 0xFE, 0x68, 0x00}))
+	assert.NoError(t, err)
+	assert.Equal(t, unutf16.EncodingUTF16LE, encoding)
+
+	// A remembered key still reads its source, and a failure there is reported
+	_, _, err = cache.Detect("template", new(errorReader))
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+}
+
+// TestDetectorCacheConcurrent tests that the cache can be used from multiple goroutines
+func TestDetectorCacheConcurrent(t *testing.T) {
+	var cache unutf16.DetectorCache
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			encoding, _, err := cache.Detect("template", bytes.NewReader([]byte{0xFE, 0xFF, 0x00, 0x68}))
+			assert.NoError(t, err)
+			assert.Equal(t, unutf16.EncodingUTF16BE, encoding)
+			cache.Forget("other")
+		}()
+	}
+	wg.Wait()
+}
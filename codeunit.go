@@ -0,0 +1,96 @@
+package unutf16
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// CodeUnitReader reads the UTF-16 code units of a stream rather than decoding them to UTF-8,
+// such as for binary formats that embed UTF-16 text. The byte order is detected from the BOM
+// the same way a Reader detects it, and the BOM itself is not returned as a code unit.
+type CodeUnitReader struct {
+	source io.Reader // Underlying source reader (UTF-16 encoded)
+	config config    // Settings applied through the options passed to NewCodeUnitReader
+
+	input    *bufio.Reader    // Input following the BOM, set up by the first ReadUint16 call
+	order    binary.ByteOrder // Byte order of the detected encoding
+	encoding Encoding         // Encoding detected during initialization
+	err      error            // Sticky error of the initialization
+}
+
+// NewCodeUnitReader initializes a new CodeUnitReader that wraps an existing io.Reader.
+// Like NewReader, it does not inspect the input until the first ReadUint16 call is made.
+// Input without a BOM is only accepted with WithDefaultEndianness, or when WithSniff
+// recognizes it as UTF-16; options that only affect decoding to UTF-8 have no effect.
+func NewCodeUnitReader(r io.Reader, opts ...Option) *CodeUnitReader {
+	return &CodeUnitReader{
+		source: r,
+		config: newConfig(opts),
+	}
+}
+
+// ReadUint16 returns the next code unit of the input, with the detected byte order applied.
+// It returns io.EOF at the end of the input, and ErrOddLength if the input ends in the middle
+// of a code unit. If the input is not UTF-16, such as UTF-8 or UTF-32, the first call returns
+// an error wrapping ErrUnsupportedEncoding.
+func (r *CodeUnitReader) ReadUint16() (uint16, error) {
+	if r.input == nil {
+		if r.err == nil {
+			r.err = r.initialize()
+		}
+		if r.err != nil {
+			return 0, r.err
+		}
+	}
+
+	var unit [2]byte
+	_, err := io.ReadFull(r.input, unit[:])
+	if err == io.ErrUnexpectedEOF {
+		return 0, ErrOddLength
+	}
+	if err != nil {
+		return 0, err
+	}
+	return r.order.Uint16(unit[:]), nil
+}
+
+// DetectedEncoding returns the encoding detected on the first ReadUint16 call,
+// which is EncodingUnknown until then.
+func (r *CodeUnitReader) DetectedEncoding() Encoding {
+	return r.encoding
+}
+
+// initialize detects the byte order from the BOM and sets up the input following it.
+func (r *CodeUnitReader) initialize() error {
+	// Options that cannot be honored make every read fail
+	if r.config.err != nil {
+		return r.config.err
+	}
+
+	// A source that keeps returning nothing must not make the peek spin
+	guarded := &emptyReadGuard{source: r.source, limit: r.config.emptyReadsLimit()}
+
+	prefix, err := peek(guarded, r.config.peekLen())
+	if err != nil {
+		return err
+	}
+
+	encoding, bomLen, err := r.config.detect(prefix)
+	if err != nil {
+		return err
+	}
+	switch encoding {
+	case EncodingUTF16LE:
+		r.order = binary.LittleEndian
+	case EncodingUTF16BE:
+		r.order = binary.BigEndian
+	default:
+		return fmt.Errorf("cannot read code units of %v: %w", encoding, ErrUnsupportedEncoding)
+	}
+
+	r.encoding = encoding
+	r.input = bufio.NewReaderSize(stitch(prefix[bomLen:], guarded), r.config.bufferLen())
+	return nil
+}
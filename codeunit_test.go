@@ -0,0 +1,103 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestCodeUnitReader tests reading the code units of UTF-16 input in the byte order of its BOM
+func TestCodeUnitReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected []uint16
+		encoding unutf16.Encoding
+	}{
+		// UTF-16LE data (BOM + "h" + U+1F600 as a surrogate pair)
+		{
+			name:     "utf16le",
+			input:    []byte{0xFF, 0xFE, 0x68, 0x00, 0x3D, 0xD8, 0x00, 0xDE},
+			expected: []uint16{0x0068, 0xD83D, 0xDE00},
+			encoding: unutf16.EncodingUTF16LE,
+		},
+		// UTF-16BE data (BOM + "h" + U+1F600 as a surrogate pair)
+		{
+			name:     "utf16be",
+			input:    []byte{0xFE, 0xFF, 0x00, 0x68, 0xD8, 0x3D, 0xDE, 0x00},
+			expected: []uint16{0x0068, 0xD83D, 0xDE00},
+			encoding: unutf16.EncodingUTF16BE,
+		},
+		// A lone surrogate is returned as is
+		{
+			name:     "lone surrogate",
+			input:    []byte{0xFF, 0xFE, 0x00, 0xDC},
+			expected: []uint16{0xDC00},
+			encoding: unutf16.EncodingUTF16LE,
+		},
+		{
+			name:     "bom only",
+			input:    []byte{0xFE, 0xFF},
+			encoding: unutf16.EncodingUTF16BE,
+		},
+		{
+			name:     "default endianness",
+			input:    []byte{0x00, 0x68, 0x00, 0x69},
+			opts:     []unutf16.Option{unutf16.WithDefaultEndianness(unicode.BigEndian)},
+			expected: []uint16{0x0068, 0x0069},
+			encoding: unutf16.EncodingUTF16BE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read one byte at a time so code units end up split across reads
+			reader := unutf16.NewCodeUnitReader(iotest.OneByteReader(bytes.NewReader(tt.input)), tt.opts...)
+
+			var units []uint16
+			for {
+				unit, err := reader.ReadUint16()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Error reading code unit: %v", err)
+				}
+				units = append(units, unit)
+			}
+
+			assert.Equal(t, tt.expected, units)
+			assert.Equal(t, tt.encoding, reader.DetectedEncoding())
+		})
+	}
+}
+
+// TestCodeUnitReaderErrors tests that input that cannot be read as code units is rejected
+func TestCodeUnitReaderErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr error
+	}{
+		{name: "no bom", input: []byte("hi"), expectedErr: unutf16.ErrUnsupportedEncoding},
+		{name: "utf8 bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}, expectedErr: unutf16.ErrUnsupportedEncoding},
+		{name: "utf32le", input: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00}, expectedErr: unutf16.ErrUnsupportedEncoding},
+		{name: "odd length", input: []byte{0xFF, 0xFE, 0x68}, expectedErr: unutf16.ErrOddLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := unutf16.NewCodeUnitReader(bytes.NewReader(tt.input))
+
+			_, err := reader.ReadUint16()
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package unutf16
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// ErrTooLarge is returned by ReadAllLimit when the decoded output exceeds the limit.
+var ErrTooLarge = errors.New("decoded output exceeds the size limit")
+
+// DecodeBytes converts an in-memory payload to UTF-8.
+// It performs the same BOM detection as Reader, so UTF-16 input is decoded
+// and any other input is passed through unmodified.
+// The returned slice is always a fresh allocation and never aliases b.
+func DecodeBytes(b []byte) ([]byte, error) {
+	return io.ReadAll(NewReader(bytes.NewReader(b)))
+}
+
+// Decode converts src to UTF-8 like DecodeBytes, but writes the result into dst and
+// returns the number of bytes written, for hot paths that reuse their buffers. It returns
+// io.ErrShortBuffer, along with the number of bytes written so far, if dst is too small to
+// hold the complete result. Decode does not allocate memory, unless src is UTF-32.
+func Decode(dst, src []byte) (int, error) {
+	encoding, bomLen := detectBOM(src)
+	src = src[bomLen:]
+
+	var (
+		n   int
+		err error
+	)
+	switch encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		// Kept on the stack rather than obtained from Encoding.transformer
+		d := utf16Decoder{endianness: unicode.BigEndian, replacement: utf8.RuneError}
+		if encoding == EncodingUTF16LE {
+			d.endianness = unicode.LittleEndian
+		}
+		n, _, err = d.Transform(dst, src, true)
+	case EncodingUTF32LE, EncodingUTF32BE:
+		n, _, err = encoding.transformer().Transform(dst, src, true)
+	default:
+		n = copy(dst, src)
+		if n < len(src) {
+			err = transform.ErrShortDst
+		}
+	}
+
+	if err == transform.ErrShortDst {
+		err = io.ErrShortBuffer
+	}
+	return n, err
+}
+
+// DecodeString converts an in-memory payload to a UTF-8 string.
+// UTF-16 input is detected by its BOM and decoded, any other input is
+// interpreted as UTF-8 and returned unchanged. A leading BOM, including a
+// UTF-8 BOM, is never part of the returned string.
+func DecodeString(b []byte) (string, error) {
+	decoded, err := DecodeBytes(b)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// DecodeStringInput converts a payload that arrived as a Go string, such as UTF-16 text
+// returned by a database driver, to a UTF-8 string. It behaves exactly like DecodeString
+// on the same bytes, without converting s to a byte slice first.
+func DecodeStringInput(s string) (string, error) {
+	var decoded strings.Builder
+	_, err := io.Copy(&decoded, NewReader(strings.NewReader(s)))
+	if err != nil {
+		return "", err
+	}
+	return decoded.String(), nil
+}
+
+// DecodeBase64 converts a payload that arrived as standard base64, such as UTF-16 text
+// shuttled in a JSON field, to a UTF-8 string. It decodes the base64 first and then behaves
+// like DecodeString. If the base64 is malformed, the returned error wraps the
+// base64.CorruptInputError, so it can be told apart from errors of the second stage.
+func DecodeBase64(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode base64: %w", err)
+	}
+	return DecodeString(b)
+}
+
+// DecodeReader reads r until EOF and returns the decoded UTF-8 text as a *strings.Reader,
+// for consumers that need to seek or re-read it. It performs the same BOM detection as Reader.
+// Note that the whole decoded input is held in memory, so this is only appropriate for
+// small payloads such as configuration files; see ReadAllLimit for untrusted input.
+func DecodeReader(r io.Reader) (*strings.Reader, error) {
+	var decoded strings.Builder
+	_, err := io.Copy(&decoded, NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(decoded.String()), nil
+}
+
+// ReadAllLimit reads r until EOF and returns the decoded UTF-8 bytes, like io.ReadAll
+// on a Reader. It returns ErrTooLarge as soon as the decoded output would exceed limit bytes.
+// The limit applies to the output rather than the input, since converting UTF-16 to UTF-8
+// can grow the data, which protects against inputs that are small but blow up when decoded.
+// A negative limit is rejected with a *ConfigError, and math.MaxInt64 reads without a limit.
+func ReadAllLimit(r io.Reader, limit int64) ([]byte, error) {
+	if limit < 0 {
+		return nil, &ConfigError{Reason: fmt.Sprintf("limit %d is negative", limit)}
+	}
+	if limit == math.MaxInt64 {
+		// No output can exceed this, and there is no byte past it to read
+		return io.ReadAll(NewReader(r))
+	}
+
+	// Read one byte past the limit to tell an exact fit apart from an overflow
+	decoded, err := io.ReadAll(io.LimitReader(NewReader(r), limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decoded)) > limit {
+		return nil, ErrTooLarge
+	}
+	return decoded, nil
+}
+
+// DecodeDocuments reads r until EOF and splits it into documents that are concatenated back
+// to back, such as batches dumped by some legacy exporters, returning the decoded UTF-8 of
+// each. Every document is detected by its own BOM like DecodeBytes does, so their byte orders
+// and encodings may differ. A document ends where the next BOM starts on a code unit boundary
+// following its own BOM: every two bytes for UTF-16, every four bytes for UTF-32, and every
+// byte for UTF-8. Inside a UTF-16 or UTF-32 document only UTF-16 and UTF-32 BOMs count, as the
+// bytes of a UTF-8 BOM there are part of other characters. Each BOM starts a new document, so a
+// U+FEFF used as a character in the middle of a document splits it, and a BOM right after another
+// one yields an empty document. Input that does not start with a BOM forms a first document that
+// is passed through as UTF-8.
+func DecodeDocuments(r io.Reader) ([][]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents [][]byte
+	for len(data) > 0 {
+		end := documentLen(data)
+		decoded, err := DecodeBytes(data[:end])
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode document %d: %w", len(documents), err)
+		}
+		documents = append(documents, decoded)
+		data = data[end:]
+	}
+	return documents, nil
+}
+
+// documentLen returns the length of the document at the start of data, which ends
+// where the next BOM starts on a code unit boundary following its own BOM.
+func documentLen(data []byte) int {
+	encoding, bomLen := detectBOM(data)
+	wide := encoding.unitLen() > 1
+	// The search starts past the BOM of the document itself, if there is one
+	for i := max(bomLen, 1); i < len(data); i += encoding.unitLen() {
+		next, _ := detectBOM(data[i:])
+		if next == EncodingUTF8BOM && wide {
+			continue
+		}
+		if next != EncodingPassthrough {
+			return i
+		}
+	}
+	return len(data)
+}
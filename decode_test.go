@@ -0,0 +1,326 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestDecodeBytes tests one-shot decoding of UTF-16 payloads
+func TestDecodeBytes(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	output, err := unutf16.DecodeBytes(utf16leData)
+	if err != nil {
+		t.Fatalf("Error decoding bytes: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestDecode tests decoding into a provided buffer
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}, expected: "hé"},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0xE9}, expected: "hé"},
+		{name: "utf32le", input: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00}, expected: "h"},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}, expected: "hi"},
+		{name: "utf8", input: []byte("hi"), expected: "hi"},
+		{name: "empty", input: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := make([]byte, 16)
+			n, err := unutf16.Decode(dst, tt.input)
+			if err != nil {
+				t.Fatalf("Error decoding bytes: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(dst[:n]))
+		})
+	}
+}
+
+// TestDecodeShortBuffer tests that a buffer too small for the result is reported
+func TestDecodeShortBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		// UTF-16LE data (BOM + "hé"), where the "é" does not fit anymore
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}, expected: "h"},
+		{name: "utf8", input: []byte("hello"), expected: "he"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := make([]byte, 2)
+			n, err := unutf16.Decode(dst, tt.input)
+
+			assert.ErrorIs(t, err, io.ErrShortBuffer)
+			assert.Equal(t, tt.expected, string(dst[:n]))
+		})
+	}
+}
+
+// TestDecodeAllocations tests that decoding UTF-16 into a large enough buffer does not allocate
+func TestDecodeAllocations(t *testing.T) {
+	// UTF-16LE data (BOM + "hé")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}
+	dst := make([]byte, 16)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = unutf16.Decode(dst, utf16leData)
+	})
+
+	assert.Zero(t, allocs)
+}
+
+// TestDecodeBytesPassthrough tests that UTF-8 payloads are returned as a copy
+func TestDecodeBytesPassthrough(t *testing.T) {
+	utf8Data := []byte("hello world")
+
+	output, err := unutf16.DecodeBytes(utf8Data)
+	if err != nil {
+		t.Fatalf("Error decoding bytes: %v", err)
+	}
+
+	assert.Equal(t, "hello world", string(output))
+
+	// The result must not alias the input
+	output[0] = 'H'
+	assert.Equal(t, "hello world", string(utf8Data))
+}
+
+// TestDecodeString tests one-shot decoding of payloads into strings
+func TestDecodeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: "hi"},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}, expected: "hi"},
+		{name: "utf8", input: []byte("hi"), expected: "hi"},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}, expected: "hi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := unutf16.DecodeString(tt.input)
+			if err != nil {
+				t.Fatalf("Error decoding string: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, output)
+		})
+	}
+}
+
+// TestDecodeReader tests decoding into a seekable reader
+func TestDecodeReader(t *testing.T) {
+	// UTF-16BE data (BOM + "hello")
+	utf16beData := []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F}
+
+	reader, err := unutf16.DecodeReader(bytes.NewReader(utf16beData))
+	if err != nil {
+		t.Fatalf("Error decoding reader: %v", err)
+	}
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Error reading from decoded reader: %v", err)
+	}
+	assert.Equal(t, "hello", string(output))
+
+	// The decoded text can be read again
+	_, err = reader.Seek(1, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Error seeking decoded reader: %v", err)
+	}
+	output, err = io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Error reading from decoded reader: %v", err)
+	}
+	assert.Equal(t, "ello", string(output))
+}
+
+// TestDecodeReaderFailure tests that read failures are reported
+func TestDecodeReaderFailure(t *testing.T) {
+	reader, err := unutf16.DecodeReader(new(errorReader))
+
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Nil(t, reader)
+}
+
+// TestDecodeStringInput tests that payloads given as strings decode like the same bytes
+func TestDecodeStringInput(t *testing.T) {
+	inputs := []string{
+		"\xFF\xFEh\x00i\x00",
+		"\xFE\xFF\x00h\x00i",
+		"\xEF\xBB\xBFhi",
+		"hi",
+		"",
+	}
+
+	for _, input := range inputs {
+		output, err := unutf16.DecodeStringInput(input)
+		if err != nil {
+			t.Fatalf("Error decoding string: %v", err)
+		}
+
+		expected, err := unutf16.DecodeString([]byte(input))
+		if err != nil {
+			t.Fatalf("Error decoding string: %v", err)
+		}
+		assert.Equal(t, expected, output, "input %q", input)
+	}
+}
+
+// TestDecodeBase64 tests decoding base64 encoded payloads
+func TestDecodeBase64(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		// UTF-16LE data (BOM + "hi")
+		{name: "utf16le", input: "//5oAGkA", expected: "hi"},
+		{name: "utf8", input: "aGk=", expected: "hi"},
+		{name: "empty", input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := unutf16.DecodeBase64(tt.input)
+			if err != nil {
+				t.Fatalf("Error decoding base64: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, output)
+		})
+	}
+}
+
+// TestDecodeBase64Invalid tests that malformed base64 is reported as such
+func TestDecodeBase64Invalid(t *testing.T) {
+	_, err := unutf16.DecodeBase64("//5o!GkA")
+
+	var corruptErr base64.CorruptInputError
+	assert.ErrorAs(t, err, &corruptErr)
+	assert.EqualError(t, err, "cannot decode base64: illegal base64 data at input byte 4")
+}
+
+// TestReadAllLimit tests that the size limit applies to the decoded output
+func TestReadAllLimit(t *testing.T) {
+	// UTF-16LE data (BOM + "héé"), 8 bytes of input and 5 bytes of output
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0xE9, 0x00}
+
+	output, err := unutf16.ReadAllLimit(bytes.NewReader(utf16leData), 5)
+	if err != nil {
+		t.Fatalf("Error reading with limit: %v", err)
+	}
+	assert.Equal(t, "héé", string(output))
+
+	output, err = unutf16.ReadAllLimit(bytes.NewReader(utf16leData), 4)
+	assert.ErrorIs(t, err, unutf16.ErrTooLarge)
+	assert.Nil(t, output)
+}
+
+// TestReadAllLimitMax tests that the largest limit does not overflow into reading nothing
+func TestReadAllLimitMax(t *testing.T) {
+	output, err := unutf16.ReadAllLimit(strings.NewReader("hello"), math.MaxInt64)
+	if err != nil {
+		t.Fatalf("Error reading with limit: %v", err)
+	}
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestReadAllLimitNegative tests that a negative limit is rejected
+func TestReadAllLimitNegative(t *testing.T) {
+	output, err := unutf16.ReadAllLimit(strings.NewReader("hello"), -1)
+
+	assert.IsType(t, new(unutf16.ConfigError), err)
+	assert.EqualError(t, err, "invalid configuration: limit -1 is negative")
+	assert.Nil(t, output)
+}
+
+// TestDecodeDocuments tests that concatenated documents are split at each BOM on a code unit boundary
+func TestDecodeDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []string
+	}{
+		{name: "empty", input: []byte{}},
+		{name: "single", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: []string{"hi"}},
+		// UTF-16LE "hi" followed by UTF-16BE "yo"
+		{
+			name:     "mixed byte order",
+			input:    []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00, 0xFE, 0xFF, 0x00, 0x79, 0x00, 0x6F},
+			expected: []string{"hi", "yo"},
+		},
+		// UTF-8 without a BOM followed by UTF-8 with a BOM and UTF-16LE "é"
+		{
+			name:     "utf8 first",
+			input:    []byte{0x61, 0x62, 0xEF, 0xBB, 0xBF, 0x63, 0xFF, 0xFE, 0xE9, 0x00},
+			expected: []string{"ab", "c", "é"},
+		},
+		// UTF-32BE "h" followed by UTF-16LE "i"
+		{
+			name:     "utf32",
+			input:    []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0xFF, 0xFE, 0x69, 0x00},
+			expected: []string{"h", "i"},
+		},
+		// The bytes 0xFF 0xFE straddle two UTF-16BE code units, so they are not a BOM
+		{
+			name:     "unaligned",
+			input:    []byte{0xFE, 0xFF, 0x00, 0xFF, 0xFE, 0x00},
+			expected: []string{"ÿ︀"},
+		},
+		// The bytes of a UTF-8 BOM form part of "\uBBEF\uC5BF" in a UTF-16LE document
+		{
+			name:     "utf8 bom inside utf16",
+			input:    []byte{0xFF, 0xFE, 0xEF, 0xBB, 0xBF, 0xC5},
+			expected: []string{"\uBBEF\uC5BF"},
+		},
+		{name: "empty document", input: []byte{0xFF, 0xFE, 0xFF, 0xFE, 0x68, 0x00}, expected: []string{"", "h"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents, err := unutf16.DecodeDocuments(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Error decoding documents: %v", err)
+			}
+
+			var decoded []string
+			for _, document := range documents {
+				decoded = append(decoded, string(document))
+			}
+			assert.Equal(t, tt.expected, decoded)
+		})
+	}
+}
+
+// TestDecodeDocumentsFailure tests that read failures are reported
+func TestDecodeDocumentsFailure(t *testing.T) {
+	documents, err := unutf16.DecodeDocuments(new(errorReader))
+
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Nil(t, documents)
+}
@@ -0,0 +1,539 @@
+package unutf16
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+// utf8BOM is the Byte Order Mark of UTF-8 encoded text.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// maxSniffLen is the upper bound for the sample WithSniff inspects, so that
+// sniffing never buffers more than a small part of a large input.
+const maxSniffLen = 64 * 1024
+
+// autoCorrectSampleLen is the size of the sample WithAutoCorrectEndianness checks the
+// byte order of a UTF-16 BOM against.
+const autoCorrectSampleLen = 256
+
+// autoCorrectMinUnits is the number of code units following the BOM that
+// WithAutoCorrectEndianness needs at least before it overrules the BOM.
+const autoCorrectMinUnits = 8
+
+// autoCorrectConfidence is the confidence of SniffEncodingConfidence at or above which
+// WithAutoCorrectEndianness overrules a UTF-16 BOM.
+const autoCorrectConfidence = 0.9
+
+// swapCheckUnits is the number of code units following a UTF-16 BOM that are checked
+// for looking byte-swapped, which DetectedAnomaly reports.
+const swapCheckUnits = 8
+
+// swapCheckMin is the number of the checked code units that have to look byte-swapped,
+// being printable ASCII only with their bytes swapped, for DetectedAnomaly to report it.
+const swapCheckMin = 6
+
+// maxBOMLen is the number of leading bytes that are inspected for a BOM.
+// It is the length of the longest supported BOM, which belongs to UTF-32.
+const maxBOMLen = 4
+
+// Detect peeks the BOM of r and reports the encoding it indicates, or
+// EncodingPassthrough if there is none. The returned io.Reader replays the
+// peeked bytes followed by the rest of r, so the complete stream, including
+// the BOM, remains available to whichever consumer the caller picks.
+func Detect(r io.Reader) (Encoding, io.Reader, error) {
+	prefix, err := peekBOM(r)
+	if err != nil {
+		return EncodingUnknown, nil, err
+	}
+
+	encoding, _ := detectBOM(prefix)
+	return encoding, stitch(prefix, r), nil
+}
+
+// DetectAt reads the BOM window at offset 0 of r and reports the encoding it
+// indicates, or EncodingPassthrough if there is none. Unlike Detect it does not
+// consume anything, since r is read at an explicit offset, and no decoder is built.
+// If reading fails, a *BOMPeekError is returned.
+func DetectAt(r io.ReaderAt) (Encoding, error) {
+	encoding, _, err := detectAt(r)
+	return encoding, err
+}
+
+// detectAt reads the BOM window at offset 0 of r and returns the encoding it indicates
+// along with the length of the BOM.
+func detectAt(r io.ReaderAt) (Encoding, int, error) {
+	prefix, err := peekAt(r, maxBOMLen)
+	if err != nil {
+		return EncodingUnknown, 0, err
+	}
+
+	encoding, bomLen := detectBOM(prefix)
+	return encoding, bomLen, nil
+}
+
+// peekAt reads up to size bytes at offset 0 of r, returning fewer if r is shorter.
+// If reading fails, a *BOMPeekError is returned.
+func peekAt(r io.ReaderAt, size int) ([]byte, error) {
+	prefix := make([]byte, size)
+	n, err := r.ReadAt(prefix, 0)
+	if err != nil && err != io.EOF {
+		return nil, &BOMPeekError{
+			Cause:     err,
+			Partial:   bytes.Clone(prefix[:n]),
+			N:         n,
+			Truncated: errors.Is(err, io.ErrUnexpectedEOF),
+		}
+	}
+	return prefix[:n], nil
+}
+
+// DetectFile opens the named file and detects its encoding like a Reader does.
+// It returns the encoding along with an io.ReadCloser that decodes the file to UTF-8
+// and closes the file on Close. If the file cannot be opened, the error of os.Open
+// is returned as is. If peeking the BOM fails, the file is closed again and the
+// *BOMPeekError is returned.
+func DetectFile(name string) (Encoding, io.ReadCloser, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return EncodingUnknown, nil, err
+	}
+
+	reader, err := openReader(file)
+	if err != nil {
+		return EncodingUnknown, nil, err
+	}
+
+	return reader.DetectedEncoding(), reader, nil
+}
+
+// ClassifyDir walks the directory tree rooted at root and detects the encoding of every
+// regular file in it like DetectFile does, without decoding its contents, such as to audit
+// the files of a migration. It returns a map from the path of each file, as passed to
+// DetectFile, to its encoding. Symbolic links are not followed, and other files that are not
+// regular, such as named pipes, are skipped. A file that cannot be opened or peeked, or
+// a directory that cannot be read, does not abort the walk; its error is joined with those
+// of the others into the returned error, and the map holds every file that was classified.
+func ClassifyDir(root string) (map[string]Encoding, error) {
+	encodings := make(map[string]Encoding)
+	var errs []error
+
+	// The walk itself never fails, since every error is collected instead
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			// Skip the directory that cannot be read, but keep walking the others
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		encoding, reader, err := DetectFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot classify %s: %w", path, err))
+			return nil
+		}
+		encodings[path] = encoding
+		if err := reader.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("cannot close %s: %w", path, err))
+		}
+		return nil
+	})
+
+	return encodings, errors.Join(errs...)
+}
+
+// Open opens the named file of fsys, such as an embed.FS or a zip archive, and returns
+// an io.ReadCloser that decodes it to UTF-8 and closes the file on Close. If the file
+// cannot be opened, the error of fsys is returned as is, so fs.ErrNotExist can be matched.
+// If peeking the BOM fails, the file is closed again and the *BOMPeekError is returned.
+func Open(fsys fs.FS, name string) (io.ReadCloser, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := openReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// NewDetectingReader initializes a new Reader like NewReader and detects the encoding of r
+// right away, rather than on the first Read, for sources that cannot seek such as a socket.
+// It reads up to sniffLen bytes, or the 4 bytes of a BOM if that is more, and detects the
+// encoding from a BOM first and by SniffEncoding second, as WithSniff does; a sniffLen of 0
+// only detects a BOM. The bytes read are kept for the Reader, so none of them is lost to the
+// stream. Options are applied on top of WithSniff(sniffLen). If peeking fails, the
+// *BOMPeekError is returned, and so is a *ConfigError for options that cannot be honored.
+func NewDetectingReader(r io.Reader, sniffLen int, opts ...Option) (*Reader, Encoding, error) {
+	reader := NewReader(r, append([]Option{WithSniff(sniffLen)}, opts...)...)
+	err := reader.initialize()
+	if err != nil {
+		return nil, EncodingUnknown, err
+	}
+	return reader, reader.DetectedEncoding(), nil
+}
+
+// openReader wraps an opened file in a Reader and initializes it right away, so the
+// encoding is known before the first Read. The file is closed again if that fails.
+func openReader(file io.ReadCloser, opts ...Option) (*Reader, error) {
+	reader := NewReader(file, opts...)
+	err := reader.initialize()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// peekBOM reads the BOM window from the start of r. Like a Reader, it retries reads that
+// return neither data nor an error, but fails with io.ErrNoProgress if r keeps doing so.
+func peekBOM(r io.Reader) ([]byte, error) {
+	return peek(&emptyReadGuard{source: r, limit: defaultMaxEmptyReads}, maxBOMLen)
+}
+
+// peek reads up to size bytes from the start of r. A single Read may legitimately
+// return fewer bytes than requested, or none at all before data is ready, such as
+// for a pipe, so it keeps reading until the window is full or the source is exhausted,
+// in which case the returned prefix is shorter. It is up to the caller to guard
+// against a source that never makes progress, such as with an emptyReadGuard.
+// Unlike io.ReadFull, it tells a source that is exhausted apart from one that
+// reports io.ErrUnexpectedEOF itself, which is a failure.
+func peek(r io.Reader, size int) ([]byte, error) {
+	return peekUntil(r, size, nil)
+}
+
+// peekUntil reads up to size bytes from the start of r like peek does, but stops
+// early once done, if not nil, reports that the bytes read so far are enough.
+func peekUntil(r io.Reader, size int, done func(prefix []byte) bool) ([]byte, error) {
+	prefix := make([]byte, size)
+	var n int
+	for n < size {
+		m, err := r.Read(prefix[n:])
+		n += m
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &BOMPeekError{
+				Cause:     err,
+				Partial:   bytes.Clone(prefix[:n]),
+				N:         n,
+				Truncated: errors.Is(err, io.ErrUnexpectedEOF),
+			}
+		}
+		if done != nil && n > 0 && done(prefix[:n]) {
+			break
+		}
+	}
+	return prefix[:n], nil
+}
+
+// stitch returns an io.Reader that yields prefix followed by the rest of r.
+// Without a prefix, r is returned as is.
+func stitch(prefix []byte, r io.Reader) io.Reader {
+	if len(prefix) == 0 {
+		return r
+	}
+	return io.MultiReader(bytes.NewReader(prefix), r)
+}
+
+// supportedBOMs lists the BOMs detectBOM recognizes.
+var supportedBOMs = [][]byte{
+	{0xFF, 0xFE, 0x00, 0x00},
+	{0x00, 0x00, 0xFE, 0xFF},
+	{0xEF, 0xBB, 0xBF},
+	{0xFF, 0xFE},
+	{0xFE, 0xFF},
+}
+
+// decided reports whether the leading bytes of a stream are enough to detect its
+// encoding according to the configuration, because more bytes cannot change the result.
+// That is the case once they can no longer be the start of a longer BOM that matters.
+func (c *config) decided(prefix []byte) bool {
+	candidates := supportedBOMs
+	if c.strictBOM {
+		for _, unsupported := range unsupportedBOMs {
+			candidates = append(candidates[:len(candidates):len(candidates)], unsupported.bom)
+		}
+	}
+
+	for _, bom := range candidates {
+		if len(prefix) < len(bom) && bytes.HasPrefix(bom, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectBOM inspects the leading bytes of a stream and returns the encoding
+// indicated by its Byte Order Mark (BOM) along with the length of that BOM.
+// If no BOM is present it returns EncodingPassthrough and a length of 0.
+func detectBOM(prefix []byte) (Encoding, int) {
+	switch {
+	// The UTF-32LE BOM starts with the UTF-16LE BOM, so it has to be checked first.
+	// This means UTF-16LE input starting with a NUL character is read as UTF-32LE.
+	case len(prefix) >= 4 && prefix[0] == 0xFF && prefix[1] == 0xFE && prefix[2] == 0x00 && prefix[3] == 0x00:
+		return EncodingUTF32LE, 4
+	case len(prefix) >= 4 && prefix[0] == 0x00 && prefix[1] == 0x00 && prefix[2] == 0xFE && prefix[3] == 0xFF:
+		return EncodingUTF32BE, 4
+	case len(prefix) >= 3 && prefix[0] == 0xEF && prefix[1] == 0xBB && prefix[2] == 0xBF:
+		return EncodingUTF8BOM, 3
+	case len(prefix) >= 2 && prefix[0] == 0xFF && prefix[1] == 0xFE:
+		return EncodingUTF16LE, 2
+	case len(prefix) >= 2 && prefix[0] == 0xFE && prefix[1] == 0xFF:
+		return EncodingUTF16BE, 2
+	default:
+		return EncodingPassthrough, 0
+	}
+}
+
+// ErrMissingBOM is returned by a Reader created with WithRequireBOM when its input does not
+// start with a supported BOM.
+var ErrMissingBOM = errors.New("input does not start with a BOM")
+
+// detect determines the encoding of a stream from its leading bytes according to
+// the configuration, and returns it along with the length of the BOM to skip.
+// A BOM wins unless a charset hint that disagrees with it has priority, followed by
+// the hint, sniffing and finally the default endianness or the fallback encoding,
+// unless the encoding is forced.
+// It fails if the configuration rejects what the leading bytes indicate.
+// The decision that settled the encoding is reported to the logger.
+func (c *config) detect(prefix []byte) (Encoding, int, error) {
+	// The caller opted out of detection altogether
+	if c.forced != EncodingUnknown {
+		c.logf("forced %v, skipping detection", c.forced)
+		return c.forced, 0, nil
+	}
+
+	encoding, bomLen := detectBOM(prefix)
+
+	// A BOM of an encoding we cannot decode must not be passed through if asked to
+	if encoding == EncodingPassthrough && c.strictBOM {
+		if name, bom := detectUnsupportedBOM(prefix); bom != nil {
+			c.log("rejected the BOM of an unsupported encoding")
+			return EncodingUnknown, 0, &UnsupportedBOMError{
+				Name: name,
+				BOM:  bytes.Clone(bom),
+			}
+		}
+	}
+
+	// Input without a BOM must not be guessed at if asked to
+	if encoding == EncodingPassthrough && c.requireBOM {
+		c.log("no BOM found, rejecting the input")
+		return EncodingUnknown, 0, ErrMissingBOM
+	}
+
+	// The label of the input wins over a BOM that disagrees with it if asked to
+	if encoding != EncodingPassthrough && c.hint != nil && c.bomPriority == HintWins && !hintAgrees(c.hint, encoding) {
+		c.logf("BOM of %v disagrees with the charset hint, using the hint", encoding)
+		return EncodingFallback, 0, nil
+	}
+	if encoding != EncodingPassthrough {
+		c.logf("detected %v via BOM", encoding)
+		if c.autoCorrectEndianness {
+			encoding = c.correctEndianness(encoding, prefix[bomLen:])
+		}
+		return encoding, bomLen, nil
+	}
+
+	// No BOM, but the input is labeled
+	if c.hint != nil {
+		c.log("no BOM found, using the charset hint")
+		return EncodingFallback, 0, nil
+	}
+
+	// No BOM, so guess from the sample if asked to
+	if c.sniffLen > 0 {
+		if encoding = SniffEncoding(prefix); encoding != EncodingPassthrough {
+			c.logf("no BOM found, sniffed %v", encoding)
+			return encoding, 0, nil
+		}
+	}
+
+	// No BOM, but a leading null byte is most likely the high byte of a UTF-16BE code unit
+	if c.assumeBEOnLeadingNUL && len(prefix) > 0 && prefix[0] == 0x00 {
+		c.logf("no BOM found, assumed %v from the leading null byte", EncodingUTF16BE)
+		return EncodingUTF16BE, 0, nil
+	}
+
+	// No BOM, but the caller may have told us which UTF-16 byte order to assume
+	if c.hasDefaultEndianness {
+		encoding = utf16Encoding(c.defaultEndianness)
+		c.logf("no BOM found, applied default endianness %v", encoding)
+		return encoding, 0, nil
+	}
+
+	// No BOM, and the caller would rather not assume UTF-8
+	if c.fallback != nil {
+		c.log("no BOM found, using the fallback encoding")
+		return EncodingFallback, 0, nil
+	}
+
+	c.log("no BOM found, using passthrough")
+	return EncodingPassthrough, 0, nil
+}
+
+// correctEndianness returns the other UTF-16 byte order if the null bytes in the sample
+// following a UTF-16 BOM strongly suggest it, and e otherwise.
+func (c *config) correctEndianness(e Encoding, sample []byte) Encoding {
+	if e != EncodingUTF16LE && e != EncodingUTF16BE || len(sample)/2 < autoCorrectMinUnits {
+		return e
+	}
+
+	guess, confidence := SniffEncodingConfidence(sample)
+	if guess == EncodingPassthrough || guess == e || confidence < autoCorrectConfidence {
+		return e
+	}
+	c.logf("warning: the content contradicts the BOM, switching to %v", guess)
+	if c.anomaly != nil {
+		*c.anomaly = true
+	}
+	return guess
+}
+
+// printableASCII reports whether the code unit is a printable ASCII character or whitespace
+// such as a newline, which text mostly consists of.
+func printableASCII(unit uint16) bool {
+	return unit >= 0x20 && unit <= 0x7E || unit == '\t' || unit == '\n' || unit == '\r'
+}
+
+// BOMPriority decides whether a BOM or a charset hint wins when they disagree.
+type BOMPriority int
+
+const (
+	// BOMWins makes the BOM decide the encoding. This is the default.
+	BOMWins BOMPriority = iota
+	// HintWins makes the encoding passed to WithCharsetHint decide.
+	HintWins
+)
+
+// hintAgrees reports whether input labeled with hint may start with the BOM of e.
+// A UTF-16 or UTF-32 hint that honors a BOM agrees with either byte order.
+func hintAgrees(hint encoding.Encoding, e Encoding) bool {
+	switch hint {
+	case unicode.UTF8, unicode.UTF8BOM:
+		return e == EncodingUTF8BOM
+	case unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM):
+		return e == EncodingUTF16LE
+	case unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM):
+		return e == EncodingUTF16BE
+	case unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+		unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM):
+		return e == EncodingUTF16LE || e == EncodingUTF16BE
+	case utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM):
+		return e == EncodingUTF32LE
+	case utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM):
+		return e == EncodingUTF32BE
+	case utf32.UTF32(utf32.LittleEndian, utf32.UseBOM), utf32.UTF32(utf32.BigEndian, utf32.UseBOM),
+		utf32.UTF32(utf32.LittleEndian, utf32.ExpectBOM), utf32.UTF32(utf32.BigEndian, utf32.ExpectBOM):
+		return e == EncodingUTF32LE || e == EncodingUTF32BE
+	default:
+		return false
+	}
+}
+
+// SniffEncoding guesses the encoding of a sample that lacks a BOM by looking at
+// the distribution of null bytes. Text in UTF-16 that is mostly made up of ASCII
+// characters has a null byte in every code unit: in the second byte for UTF-16LE
+// and in the first byte for UTF-16BE. If neither pattern is clearly present,
+// EncodingPassthrough is returned. A BOM in the sample is not taken into account.
+func SniffEncoding(sample []byte) Encoding {
+	encoding, _ := SniffEncodingConfidence(sample)
+	return encoding
+}
+
+// SniffEncodingConfidence guesses the encoding of a sample like SniffEncoding, and also
+// reports how cleanly the null bytes match the guess as a confidence between 0 and 1.
+// For a UTF-16 guess, it is the share of code units with a null in the expected byte,
+// minus the share with a null in the other one. For EncodingPassthrough, it is the
+// share of code units without a null in either position. A sample shorter than a code
+// unit carries no evidence, so the confidence is 0. Callers that must not corrupt
+// ambiguous input can refuse to decode below a threshold of their choosing.
+func SniffEncodingConfidence(sample []byte) (Encoding, float64) {
+	units := len(sample) / 2
+	if units == 0 {
+		return EncodingPassthrough, 0
+	}
+
+	// Count null bytes in the first (even) and second (odd) byte of each code unit
+	var even, odd int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0x00 {
+			even++
+		}
+		if sample[i+1] == 0x00 {
+			odd++
+		}
+	}
+
+	// One position has to hold a null in at least half of the code units,
+	// while the other position holds nulls in at most a tenth of them
+	switch {
+	case odd*2 >= units && even*10 <= units:
+		return EncodingUTF16LE, float64(odd-even) / float64(units)
+	case even*2 >= units && odd*10 <= units:
+		return EncodingUTF16BE, float64(even-odd) / float64(units)
+	default:
+		return EncodingPassthrough, 1 - float64(max(even, odd))/float64(units)
+	}
+}
+
+// IsUTF16 reports whether sample is the start of UTF-16 input: it begins with a UTF-16LE
+// or UTF-16BE BOM, or, if minConfidence is positive, SniffEncodingConfidence guesses
+// UTF-16 with at least that confidence. Pass 0 to only accept a BOM. A sample starting
+// with the UTF-32LE BOM, which begins like the UTF-16LE one, is not UTF-16, like a Reader
+// would decide. IsUTF16 does not allocate memory.
+func IsUTF16(sample []byte, minConfidence float64) bool {
+	encoding, bomLen := detectBOM(sample)
+	if encoding == EncodingUTF16LE || encoding == EncodingUTF16BE {
+		return true
+	}
+	if bomLen > 0 || minConfidence <= 0 {
+		return false
+	}
+
+	guess, confidence := SniffEncodingConfidence(sample)
+	return guess != EncodingPassthrough && confidence >= minConfidence
+}
+
+// unsupportedBOMs lists BOMs of encodings that are recognized but cannot be decoded.
+var unsupportedBOMs = []struct {
+	name string
+	bom  []byte
+}{
+	{name: "GB18030", bom: []byte{0x84, 0x31, 0x95, 0x33}},
+	{name: "UTF-EBCDIC", bom: []byte{0xDD, 0x73, 0x66, 0x73}},
+	{name: "UTF-7", bom: []byte{0x2B, 0x2F, 0x76, 0x38}},
+	{name: "UTF-7", bom: []byte{0x2B, 0x2F, 0x76, 0x39}},
+	{name: "UTF-7", bom: []byte{0x2B, 0x2F, 0x76, 0x2B}},
+	{name: "UTF-7", bom: []byte{0x2B, 0x2F, 0x76, 0x2F}},
+	{name: "UTF-1", bom: []byte{0xF7, 0x64, 0x4C}},
+	{name: "SCSU", bom: []byte{0x0E, 0xFE, 0xFF}},
+	{name: "BOCU-1", bom: []byte{0xFB, 0xEE, 0x28}},
+}
+
+// detectUnsupportedBOM inspects the leading bytes of a stream for the BOM of an
+// encoding that cannot be decoded. It returns the name of that encoding and its BOM,
+// or a nil BOM if none is present.
+func detectUnsupportedBOM(prefix []byte) (string, []byte) {
+	for _, candidate := range unsupportedBOMs {
+		if bytes.HasPrefix(prefix, candidate.bom) {
+			return candidate.name, candidate.bom
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,825 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestDetect tests that Detect reports the encoding and replays the complete stream
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected unutf16.Encoding
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: unutf16.EncodingUTF16LE},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}, expected: unutf16.EncodingUTF16BE},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}, expected: unutf16.EncodingUTF8BOM},
+		{name: "utf32le", input: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00}, expected: unutf16.EncodingUTF32LE},
+		{name: "passthrough", input: []byte("hello"), expected: unutf16.EncodingPassthrough},
+		{name: "short", input: []byte("h"), expected: unutf16.EncodingPassthrough},
+		{name: "empty", input: nil, expected: unutf16.EncodingPassthrough},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, reader, err := unutf16.Detect(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Error detecting encoding: %v", err)
+			}
+
+			output, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Error reading from detected reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, encoding)
+			assert.Equal(t, string(tt.input), string(output))
+		})
+	}
+}
+
+// TestDetectPeekFailure tests that Detect reports peek failures as BOMPeekError
+func TestDetectPeekFailure(t *testing.T) {
+	encoding, reader, err := unutf16.Detect(new(errorReader))
+
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Equal(t, unutf16.EncodingUnknown, encoding)
+	assert.Nil(t, reader)
+}
+
+// TestSniffEncoding tests the null byte heuristic for input without a BOM
+func TestSniffEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected unutf16.Encoding
+	}{
+		{name: "utf16le", input: []byte{0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}, expected: unutf16.EncodingUTF16LE},
+		{name: "utf16be", input: []byte{0x00, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F}, expected: unutf16.EncodingUTF16BE},
+		{name: "utf8", input: []byte("hello world"), expected: unutf16.EncodingPassthrough},
+		{name: "binary", input: []byte{0x00, 0x00, 0x00, 0x00}, expected: unutf16.EncodingPassthrough},
+		{name: "single byte", input: []byte{0x00}, expected: unutf16.EncodingPassthrough},
+		{name: "empty", input: nil, expected: unutf16.EncodingPassthrough},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unutf16.SniffEncoding(tt.input))
+		})
+	}
+}
+
+// TestSniffEncodingConfidence tests that the confidence reflects how cleanly the sample matches the guess
+func TestSniffEncodingConfidence(t *testing.T) {
+	tests := []struct {
+		name       string
+		sample     []byte
+		expected   unutf16.Encoding
+		confidence float64
+	}{
+		{name: "clean utf16le", sample: []byte{0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00}, expected: unutf16.EncodingUTF16LE, confidence: 1},
+		{name: "mostly utf16be", sample: []byte{0x00, 0x68, 0x00, 0x65, 0x4E, 0x2D, 0x00, 0x6C}, expected: unutf16.EncodingUTF16BE, confidence: 0.75},
+		{name: "clean utf8", sample: []byte("hello world"), expected: unutf16.EncodingPassthrough, confidence: 1},
+		{name: "ambiguous", sample: []byte{0x00, 0x68, 0x65, 0x00, 0x6C, 0x6C, 0x6F, 0x6F}, expected: unutf16.EncodingPassthrough, confidence: 0.75},
+		{name: "empty", sample: nil, expected: unutf16.EncodingPassthrough, confidence: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, confidence := unutf16.SniffEncodingConfidence(tt.sample)
+
+			assert.Equal(t, tt.expected, encoding)
+			assert.InDelta(t, tt.confidence, confidence, 1e-9)
+			assert.Equal(t, unutf16.SniffEncoding(tt.sample), encoding)
+		})
+	}
+}
+
+// TestIsUTF16 tests that UTF-16 is recognized by its BOM, or by its null bytes if asked to
+func TestIsUTF16(t *testing.T) {
+	tests := []struct {
+		name          string
+		sample        []byte
+		minConfidence float64
+		expected      bool
+	}{
+		{name: "utf16le bom", sample: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: true},
+		{name: "utf16be bom", sample: []byte{0xFE, 0xFF, 0x00, 0x68}, expected: true},
+		{name: "utf32le bom", sample: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00}, minConfidence: 0.5, expected: false},
+		{name: "utf8 bom", sample: []byte{0xEF, 0xBB, 0xBF, 0x68}, minConfidence: 0.5, expected: false},
+		{name: "no bom without heuristic", sample: []byte{0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00}, expected: false},
+		{name: "clean utf16le", sample: []byte{0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00}, minConfidence: 0.9, expected: true},
+		{name: "mostly utf16be below threshold", sample: []byte{0x00, 0x68, 0x00, 0x65, 0x4E, 0x2D, 0x00, 0x6C}, minConfidence: 0.9, expected: false},
+		{name: "mostly utf16be above threshold", sample: []byte{0x00, 0x68, 0x00, 0x65, 0x4E, 0x2D, 0x00, 0x6C}, minConfidence: 0.7, expected: true},
+		{name: "utf8", sample: []byte("hello world"), minConfidence: 0.5, expected: false},
+		{name: "empty", sample: nil, minConfidence: 0.5, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unutf16.IsUTF16(tt.sample, tt.minConfidence))
+		})
+	}
+}
+
+// TestIsUTF16Allocations tests that IsUTF16 does not allocate memory
+func TestIsUTF16Allocations(t *testing.T) {
+	sample := []byte{0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		unutf16.IsUTF16(sample, 0.9)
+	})
+
+	assert.Zero(t, allocs)
+}
+
+// TestWithSniff tests that the Reader decodes BOM-less UTF-16 when sniffing is enabled
+func TestWithSniff(t *testing.T) {
+	// UTF-16BE data without BOM ("hello")
+	utf16beData := []byte{0x00, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16beData), unutf16.WithSniff(6))
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+	assert.Equal(t, unutf16.EncodingUTF16BE, utf8Reader.DetectedEncoding())
+}
+
+// TestWithSniffPassthrough tests that UTF-8 is still passed through when sniffing is enabled
+func TestWithSniffPassthrough(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello world")), unutf16.WithSniff(512))
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello world", string(output))
+	assert.Equal(t, unutf16.EncodingPassthrough, utf8Reader.DetectedEncoding())
+}
+
+// TestAssumeBEOnLeadingNUL tests that input without a BOM starting with a null byte is decoded as UTF-16BE
+func TestAssumeBEOnLeadingNUL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+		encoding unutf16.Encoding
+	}{
+		// UTF-16BE data without BOM ("hi")
+		{name: "leading nul", input: []byte{0x00, 0x68, 0x00, 0x69}, expected: "hi", encoding: unutf16.EncodingUTF16BE},
+		{name: "no leading nul", input: []byte("hi\x00"), expected: "hi\x00", encoding: unutf16.EncodingPassthrough},
+		{name: "empty", input: []byte{}, expected: "", encoding: unutf16.EncodingPassthrough},
+		// UTF-32BE data with BOM ("h"), where the BOM takes precedence
+		{name: "bom", input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68}, expected: "h", encoding: unutf16.EncodingUTF32BE},
+		{
+			name:     "default endianness",
+			input:    []byte{0x00, 0x68},
+			opts:     []unutf16.Option{unutf16.WithDefaultEndianness(unicode.LittleEndian)},
+			expected: "h",
+			encoding: unutf16.EncodingUTF16BE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]unutf16.Option{unutf16.WithAssumeBEOnLeadingNUL()}, tt.opts...)
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), opts...)
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, tt.encoding, utf8Reader.DetectedEncoding())
+		})
+	}
+}
+
+// TestWithMaxPeek tests that detection works within the maximum peek
+func TestWithMaxPeek(t *testing.T) {
+	// UTF-16BE data without BOM ("hello")
+	utf16beData := []byte{0x00, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16beData), unutf16.WithSniff(8), unutf16.WithMaxPeek(8))
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+	assert.Len(t, utf8Reader.Peeked(), 8)
+}
+
+// TestWithMaxPeekConfigError tests that a maximum peek below what detection needs is rejected
+func TestWithMaxPeekConfigError(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []unutf16.Option
+		expected string
+	}{
+		{
+			name:     "below bom",
+			opts:     []unutf16.Option{unutf16.WithMaxPeek(2)},
+			expected: "invalid configuration: detection needs 4 bytes, more than the maximum peek of 2",
+		},
+		{
+			name:     "below sniff",
+			opts:     []unutf16.Option{unutf16.WithSniff(512), unutf16.WithMaxPeek(64)},
+			expected: "invalid configuration: detection needs 512 bytes, more than the maximum peek of 64",
+		},
+		{
+			name:     "not positive",
+			opts:     []unutf16.Option{unutf16.WithMaxPeek(0)},
+			expected: "invalid configuration: maximum peek 0 is not positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")), tt.opts...)
+
+			_, err := utf8Reader.Read(make([]byte, 10))
+			assert.IsType(t, new(unutf16.ConfigError), err)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestStrictBOM tests that BOMs of unsupported encodings are rejected when requested
+func TestStrictBOM(t *testing.T) {
+	// GB18030 data (BOM + "hi")
+	gb18030Data := []byte{0x84, 0x31, 0x95, 0x33, 0x68, 0x69}
+
+	_, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(gb18030Data), unutf16.WithStrictBOM()))
+
+	var bomErr *unutf16.UnsupportedBOMError
+	if !errors.As(err, &bomErr) {
+		t.Fatalf("Expected an UnsupportedBOMError, got %v", err)
+	}
+	assert.Equal(t, "GB18030", bomErr.Name)
+	assert.Equal(t, []byte{0x84, 0x31, 0x95, 0x33}, bomErr.BOM)
+	assert.Equal(t, "unsupported BOM 84319533 (GB18030)", err.Error())
+}
+
+// TestStrictBOMSupported tests that supported encodings are still decoded with WithStrictBOM
+func TestStrictBOMSupported(t *testing.T) {
+	for _, input := range [][]byte{[]byte("hi"), {0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}} {
+		output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(input), unutf16.WithStrictBOM()))
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+
+		assert.Equal(t, "hi", string(output))
+	}
+}
+
+// TestRequireBOM tests that input without a supported BOM is rejected when requested
+func TestRequireBOM(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expected    string
+		expectedErr error
+	}{
+		{name: "utf8 bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}, expected: "hi"},
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: "hi"},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}, expected: "hi"},
+		{name: "utf32le", input: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00}, expected: "h"},
+		{name: "utf32be", input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68}, expected: "h"},
+		{name: "no bom", input: []byte("hi"), expectedErr: unutf16.ErrMissingBOM},
+		{name: "empty", input: []byte{}, expectedErr: unutf16.ErrMissingBOM},
+		{name: "bom not at start", input: []byte{0x20, 0xFF, 0xFE, 0x68, 0x00}, expectedErr: unutf16.ErrMissingBOM},
+		// GB18030 data (BOM + "hi")
+		{name: "unsupported bom", input: []byte{0x84, 0x31, 0x95, 0x33, 0x68, 0x69}, expectedErr: unutf16.ErrMissingBOM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Options that guess at input without a BOM must not get around the requirement
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), unutf16.WithRequireBOM(), unutf16.WithSniff(4))
+
+			output, err := io.ReadAll(utf8Reader)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestLogger tests that the decision settling the encoding is reported to the logger
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+	}{
+		{name: "bom", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: "detected UTF-16LE via BOM"},
+		{name: "passthrough", input: []byte("hi"), expected: "no BOM found, using passthrough"},
+		{
+			name:     "default endianness",
+			input:    []byte{0x00, 0x68},
+			opts:     []unutf16.Option{unutf16.WithDefaultEndianness(unicode.BigEndian)},
+			expected: "no BOM found, applied default endianness UTF-16BE",
+		},
+		{
+			name:     "sniffed",
+			input:    []byte{0x68, 0x00, 0x69, 0x00, 0x21, 0x00},
+			opts:     []unutf16.Option{unutf16.WithSniff(6)},
+			expected: "no BOM found, sniffed UTF-16LE",
+		},
+		{
+			name:     "fallback",
+			input:    []byte("hi"),
+			opts:     []unutf16.Option{unutf16.WithFallbackEncoding(charmap.Windows1252)},
+			expected: "no BOM found, using the fallback encoding",
+		},
+		{
+			name:     "hint",
+			input:    []byte("hi"),
+			opts:     []unutf16.Option{unutf16.WithCharsetHint(charmap.Windows1252)},
+			expected: "no BOM found, using the charset hint",
+		},
+		{
+			name:     "hint wins",
+			input:    []byte{0xFF, 0xFE, 0x68, 0x00},
+			opts:     []unutf16.Option{unutf16.WithCharsetHint(charmap.Windows1252), unutf16.WithBOMPriority(unutf16.HintWins)},
+			expected: "BOM of UTF-16LE disagrees with the charset hint, using the hint",
+		},
+		{
+			name:     "required bom",
+			input:    []byte("hi"),
+			opts:     []unutf16.Option{unutf16.WithRequireBOM()},
+			expected: "no BOM found, rejecting the input",
+		},
+		{
+			name:     "leading nul",
+			input:    []byte{0x00, 0x68},
+			opts:     []unutf16.Option{unutf16.WithAssumeBEOnLeadingNUL()},
+			expected: "no BOM found, assumed UTF-16BE from the leading null byte",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			logger := func(msg string) {
+				messages = append(messages, msg)
+			}
+			opts := append([]unutf16.Option{unutf16.WithLogger(logger)}, tt.opts...)
+
+			_, _ = io.ReadAll(unutf16.NewReader(bytes.NewReader(tt.input), opts...))
+
+			assert.Equal(t, []string{tt.expected}, messages)
+		})
+	}
+}
+
+// TestLoggerForced tests that a forced encoding is reported to the logger
+func TestLoggerForced(t *testing.T) {
+	var messages []string
+	logger := func(msg string) {
+		messages = append(messages, msg)
+	}
+
+	_, err := io.ReadAll(unutf16.NewReaderForced(bytes.NewReader([]byte{0x68, 0x00}), unicode.LittleEndian, unutf16.WithLogger(logger)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, []string{"forced UTF-16LE, skipping detection"}, messages)
+}
+
+// TestAutoCorrectEndianness tests that a UTF-16 BOM the content clearly contradicts is overruled when requested
+func TestAutoCorrectEndianness(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+		encoding unutf16.Encoding
+		messages []string
+		anomaly  bool
+	}{
+		// UTF-16LE BOM + UTF-16BE "hello world!"
+		{
+			name:     "wrong bom",
+			input:    append([]byte{0xFF, 0xFE}, utf16be("hello world!")...),
+			expected: "hello world!",
+			encoding: unutf16.EncodingUTF16BE,
+			messages: []string{"detected UTF-16LE via BOM", "warning: the content contradicts the BOM, switching to UTF-16BE"},
+			anomaly:  true,
+		},
+		// UTF-16BE BOM + UTF-16BE "hello world!"
+		{
+			name:     "right bom",
+			input:    append([]byte{0xFE, 0xFF}, utf16be("hello world!")...),
+			expected: "hello world!",
+			encoding: unutf16.EncodingUTF16BE,
+			messages: []string{"detected UTF-16BE via BOM"},
+		},
+		// UTF-16LE BOM + UTF-16BE "hi", too short to overrule the BOM
+		{
+			name:     "short sample",
+			input:    append([]byte{0xFF, 0xFE}, utf16be("hi")...),
+			expected: "栀椀",
+			encoding: unutf16.EncodingUTF16LE,
+			messages: []string{"detected UTF-16LE via BOM"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			logger := func(msg string) {
+				messages = append(messages, msg)
+			}
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), unutf16.WithAutoCorrectEndianness(), unutf16.WithLogger(logger))
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, tt.messages, messages)
+			assert.Equal(t, tt.encoding, utf8Reader.DetectedEncoding())
+			assert.Equal(t, tt.anomaly, utf8Reader.DetectedAnomaly())
+		})
+	}
+}
+
+// TestDetectedAnomaly tests that content that looks byte-swapped against its BOM is reported, but decoded according to the BOM
+func TestDetectedAnomaly(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+		messages []string
+		anomaly  bool
+	}{
+		// UTF-16BE BOM + UTF-16LE "hello world!"
+		{
+			name:     "swapped",
+			input:    append([]byte{0xFE, 0xFF}, utf16le("hello world!")...),
+			expected: "栀攀氀氀漀\u2000眀漀爀氀搀℀",
+			messages: []string{"detected UTF-16BE via BOM", "warning: the content looks byte-swapped against the BOM of UTF-16BE"},
+			anomaly:  true,
+		},
+		// UTF-16LE BOM + UTF-16BE "hello\r\nworld!"
+		{
+			name:     "swapped newline",
+			input:    append([]byte{0xFF, 0xFE}, utf16be("hello\r\nworld!")...),
+			expected: "栀攀氀氀漀ഀ਀眀漀爀氀搀℀",
+			messages: []string{"detected UTF-16LE via BOM", "warning: the content looks byte-swapped against the BOM of UTF-16LE"},
+			anomaly:  true,
+		},
+		// UTF-16BE BOM + UTF-16BE "hello world!"
+		{
+			name:     "right bom",
+			input:    append([]byte{0xFE, 0xFF}, utf16be("hello world!")...),
+			expected: "hello world!",
+			messages: []string{"detected UTF-16BE via BOM"},
+		},
+		// UTF-16BE BOM + UTF-16BE "日本語のテキストです"
+		{
+			name:     "cjk",
+			input:    []byte{0xFE, 0xFF, 0x65, 0xE5, 0x67, 0x2C, 0x8A, 0x9E, 0x30, 0x6E, 0x30, 0xC6, 0x30, 0xAD, 0x30, 0xB9, 0x30, 0xC8, 0x30, 0x67, 0x30, 0x59},
+			expected: "日本語のテキストです",
+			messages: []string{"detected UTF-16BE via BOM"},
+		},
+		// UTF-16BE BOM + UTF-16LE "hello", too short to be checked
+		{
+			name:     "short",
+			input:    append([]byte{0xFE, 0xFF}, utf16le("hello")...),
+			expected: "栀攀氀氀漀",
+			messages: []string{"detected UTF-16BE via BOM"},
+		},
+		// UTF-16LE data without BOM ("hello world!"), read with the wrong default endianness
+		{
+			name:     "no bom",
+			input:    utf16le("hello world!"),
+			opts:     []unutf16.Option{unutf16.WithDefaultEndianness(unicode.BigEndian)},
+			expected: "栀攀氀氀漀\u2000眀漀爀氀搀℀",
+			messages: []string{"no BOM found, applied default endianness UTF-16BE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			logger := func(msg string) {
+				messages = append(messages, msg)
+			}
+			opts := append([]unutf16.Option{unutf16.WithLogger(logger)}, tt.opts...)
+			// Read one byte at a time so the code units are checked across reads
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), opts...)
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, tt.messages, messages)
+			assert.Equal(t, tt.anomaly, utf8Reader.DetectedAnomaly())
+		})
+	}
+}
+
+// TestAutoCorrectEndiannessOff tests that the BOM decides the byte order by default
+func TestAutoCorrectEndiannessOff(t *testing.T) {
+	input := append([]byte{0xFF, 0xFE}, utf16be("hello world!")...)
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(input))
+	_, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, unutf16.EncodingUTF16LE, utf8Reader.DetectedEncoding())
+}
+
+// TestDetectAt tests that DetectAt reports the encoding from the start of a ReaderAt
+func TestDetectAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected unutf16.Encoding
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: unutf16.EncodingUTF16LE},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}, expected: unutf16.EncodingUTF16BE},
+		{name: "utf32be", input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68}, expected: unutf16.EncodingUTF32BE},
+		{name: "passthrough", input: []byte("hello"), expected: unutf16.EncodingPassthrough},
+		{name: "short", input: []byte{0xFF, 0xFE}, expected: unutf16.EncodingUTF16LE},
+		{name: "empty", input: nil, expected: unutf16.EncodingPassthrough},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bytes.NewReader(tt.input)
+			encoding, err := unutf16.DetectAt(reader)
+			if err != nil {
+				t.Fatalf("Error detecting encoding: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, encoding)
+			// Nothing has been consumed
+			assert.Equal(t, len(tt.input), reader.Len())
+		})
+	}
+}
+
+// TestDetectAtFailure tests that DetectAt reports read failures as BOMPeekError
+func TestDetectAtFailure(t *testing.T) {
+	encoding, err := unutf16.DetectAt(new(errorReaderAt))
+
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Equal(t, unutf16.EncodingUnknown, encoding)
+}
+
+// TestDetectFile tests detecting the encoding of a file and decoding it
+func TestDetectFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "utf16le.txt")
+	// UTF-16LE data (BOM + "hi")
+	err := os.WriteFile(name, []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, 0o600)
+	if err != nil {
+		t.Fatalf("Error writing test file: %v", err)
+	}
+
+	encoding, reader, err := unutf16.DetectFile(name)
+	if err != nil {
+		t.Fatalf("Error detecting file: %v", err)
+	}
+	defer reader.Close()
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Error reading from detected file: %v", err)
+	}
+
+	assert.Equal(t, unutf16.EncodingUTF16LE, encoding)
+	assert.Equal(t, "hi", string(output))
+	assert.NoError(t, reader.Close())
+}
+
+// TestDetectFileNotExist tests that errors opening the file are returned as is
+func TestDetectFileNotExist(t *testing.T) {
+	_, reader, err := unutf16.DetectFile(filepath.Join(t.TempDir(), "missing.txt"))
+
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.IsType(t, new(fs.PathError), err)
+	assert.Nil(t, reader)
+}
+
+// TestDetectFilePeekFailure tests that peek failures are reported as BOMPeekError
+func TestDetectFilePeekFailure(t *testing.T) {
+	// Reading a directory fails after opening it succeeded
+	_, reader, err := unutf16.DetectFile(t.TempDir())
+
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.Nil(t, reader)
+}
+
+// TestClassifyDir tests that every regular file of a tree is classified, while directories and symbolic links are skipped
+func TestClassifyDir(t *testing.T) {
+	root := t.TempDir()
+	files := map[string][]byte{
+		// UTF-16LE data (BOM + "hi")
+		"utf16le.txt": {0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00},
+		"utf8.txt":    []byte("hi"),
+		"empty.txt":   {},
+		// UTF-16BE data (BOM + "hi")
+		filepath.Join("sub", "utf16be.txt"): {0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69},
+	}
+	err := os.Mkdir(filepath.Join(root, "sub"), 0o700)
+	if err != nil {
+		t.Fatalf("Error creating test directory: %v", err)
+	}
+	for name, data := range files {
+		err := os.WriteFile(filepath.Join(root, name), data, 0o600)
+		if err != nil {
+			t.Fatalf("Error writing test file: %v", err)
+		}
+	}
+	err = os.Symlink(filepath.Join(root, "utf16le.txt"), filepath.Join(root, "link.txt"))
+	if err != nil {
+		t.Fatalf("Error creating test symlink: %v", err)
+	}
+
+	encodings, err := unutf16.ClassifyDir(root)
+	if err != nil {
+		t.Fatalf("Error classifying directory: %v", err)
+	}
+
+	expected := map[string]unutf16.Encoding{
+		filepath.Join(root, "utf16le.txt"):        unutf16.EncodingUTF16LE,
+		filepath.Join(root, "utf8.txt"):           unutf16.EncodingPassthrough,
+		filepath.Join(root, "empty.txt"):          unutf16.EncodingPassthrough,
+		filepath.Join(root, "sub", "utf16be.txt"): unutf16.EncodingUTF16BE,
+	}
+	assert.Equal(t, expected, encodings)
+}
+
+// TestClassifyDirUnreadable tests that a file that cannot be opened does not abort the walk
+func TestClassifyDirUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can open every file")
+	}
+
+	root := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"readable.txt": 0o600, "unreadable.txt": 0o000} {
+		err := os.WriteFile(filepath.Join(root, name), []byte("hi"), mode)
+		if err != nil {
+			t.Fatalf("Error writing test file: %v", err)
+		}
+	}
+
+	encodings, err := unutf16.ClassifyDir(root)
+
+	assert.ErrorIs(t, err, fs.ErrPermission)
+	assert.Equal(t, map[string]unutf16.Encoding{filepath.Join(root, "readable.txt"): unutf16.EncodingPassthrough}, encodings)
+}
+
+// TestClassifyDirNotExist tests that a root that does not exist is reported in the joined error
+func TestClassifyDirNotExist(t *testing.T) {
+	encodings, err := unutf16.ClassifyDir(filepath.Join(t.TempDir(), "missing"))
+
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Empty(t, encodings)
+}
+
+// TestOpen tests opening and decoding a file of an fs.FS
+func TestOpen(t *testing.T) {
+	fsys := fstest.MapFS{
+		// UTF-16BE data (BOM + "hi")
+		"utf16be.txt": &fstest.MapFile{Data: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}},
+	}
+
+	reader, err := unutf16.Open(fsys, "utf16be.txt")
+	if err != nil {
+		t.Fatalf("Error opening file: %v", err)
+	}
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Error reading from opened file: %v", err)
+	}
+
+	assert.Equal(t, "hi", string(output))
+	assert.NoError(t, reader.Close())
+}
+
+// TestOpenNotExist tests that errors opening the file are returned as is
+func TestOpenNotExist(t *testing.T) {
+	reader, err := unutf16.Open(fstest.MapFS{}, "missing.txt")
+
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.False(t, errors.As(err, new(*unutf16.BOMPeekError)))
+	assert.Nil(t, reader)
+}
+
+// TestOpenPeekFailure tests that peek failures are reported as BOMPeekError
+func TestOpenPeekFailure(t *testing.T) {
+	// Reading a directory fails after opening it succeeded
+	reader, err := unutf16.Open(os.DirFS(t.TempDir()), ".")
+
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.Nil(t, reader)
+}
+
+// TestNewDetectingReader tests that the encoding is detected up front without losing any byte of the stream
+func TestNewDetectingReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		sniffLen int
+		expected unutf16.Encoding
+	}{
+		// UTF-16LE data (BOM + "hello world!")
+		{name: "bom", input: append([]byte{0xFF, 0xFE}, utf16le("hello world!")...), sniffLen: 64, expected: unutf16.EncodingUTF16LE},
+		{name: "sniffed", input: utf16be("hello world!"), sniffLen: 64, expected: unutf16.EncodingUTF16BE},
+		{name: "sample longer than input", input: utf16le("hello world!"), sniffLen: 1024, expected: unutf16.EncodingUTF16LE},
+		{name: "passthrough", input: []byte("hello world!"), sniffLen: 64, expected: unutf16.EncodingPassthrough},
+		// UTF-16BE data (BOM + "hello world!")
+		{name: "bom only", input: append([]byte{0xFE, 0xFF}, utf16be("hello world!")...), sniffLen: 0, expected: unutf16.EncodingUTF16BE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A source that can neither seek nor hand out more than a byte per Read
+			source := struct{ io.Reader }{iotest.OneByteReader(bytes.NewReader(tt.input))}
+
+			utf8Reader, encoding, err := unutf16.NewDetectingReader(source, tt.sniffLen)
+			if err != nil {
+				t.Fatalf("Error detecting encoding: %v", err)
+			}
+			assert.Equal(t, tt.expected, encoding)
+			assert.Equal(t, tt.expected, utf8Reader.DetectedEncoding())
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+			assert.Equal(t, "hello world!", string(output))
+		})
+	}
+}
+
+// TestNewDetectingReaderFailure tests that a failing peek and invalid options are reported up front
+func TestNewDetectingReaderFailure(t *testing.T) {
+	utf8Reader, encoding, err := unutf16.NewDetectingReader(new(errorReader), 64)
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Nil(t, utf8Reader)
+	assert.Equal(t, unutf16.EncodingUnknown, encoding)
+
+	_, _, err = unutf16.NewDetectingReader(bytes.NewReader([]byte("hi")), -1)
+	var configErr *unutf16.ConfigError
+	assert.True(t, errors.As(err, &configErr))
+}
+
+// utf16be returns the UTF-16BE encoding of ASCII text s, without a BOM.
+func utf16be(s string) []byte {
+	var data []byte
+	for _, c := range []byte(s) {
+		data = append(data, 0x00, c)
+	}
+	return data
+}
+
+// utf16le returns the UTF-16LE encoding of ASCII text s, without a BOM.
+func utf16le(s string) []byte {
+	var data []byte
+	for _, c := range []byte(s) {
+		data = append(data, c, 0x00)
+	}
+	return data
+}
@@ -0,0 +1,190 @@
+package unutf16
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/unicode/utf32"
+	"golang.org/x/text/transform"
+)
+
+// Encoding identifies the encoding a Reader detected in its input.
+type Encoding int
+
+const (
+	// EncodingUnknown is reported before the input has been inspected.
+	EncodingUnknown Encoding = iota
+	// EncodingPassthrough means the input is relayed without conversion.
+	EncodingPassthrough
+	// EncodingUTF16LE means the input is decoded as UTF-16 Little Endian.
+	EncodingUTF16LE
+	// EncodingUTF16BE means the input is decoded as UTF-16 Big Endian.
+	EncodingUTF16BE
+	// EncodingUTF8BOM means the input is UTF-8 prefixed with a BOM, which is stripped.
+	EncodingUTF8BOM
+	// EncodingUTF32LE means the input is decoded as UTF-32 Little Endian.
+	EncodingUTF32LE
+	// EncodingUTF32BE means the input is decoded as UTF-32 Big Endian.
+	EncodingUTF32BE
+	// EncodingFallback means the input is decoded with the encoding passed to
+	// WithFallbackEncoding or WithCharsetHint, usually because it has no BOM.
+	EncodingFallback
+)
+
+// String returns the name of the encoding following IANA-style naming, e.g. "UTF-16LE".
+// EncodingPassthrough is named "UTF-8" and EncodingUnknown is named "unknown".
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUnknown:
+		return "unknown"
+	case EncodingPassthrough:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingUTF8BOM:
+		return "UTF-8-BOM"
+	case EncodingUTF32LE:
+		return "UTF-32LE"
+	case EncodingUTF32BE:
+		return "UTF-32BE"
+	case EncodingFallback:
+		return "fallback"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+}
+
+// HasBOM reports whether the encoding is one that is recognized by a BOM.
+// Note that a Reader may also report a UTF-16 encoding for input without a BOM,
+// if it was configured with WithSniff or WithDefaultEndianness.
+func (e Encoding) HasBOM() bool {
+	switch e {
+	case EncodingUTF16LE, EncodingUTF16BE, EncodingUTF8BOM, EncodingUTF32LE, EncodingUTF32BE:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewDecoder returns a transform.Transformer that converts input of this encoding to UTF-8
+// like a Reader that detected it does, for building a pipeline once the encoding is known,
+// such as from Detect. A leading BOM of the encoding is stripped like a Reader strips it,
+// while input without one is decoded all the same, and malformed input is replaced with
+// U+FFFD. EncodingPassthrough relays the input unchanged. For EncodingUnknown,
+// EncodingFallback and values that name no encoding, every Transform call returns an error
+// wrapping ErrUnsupportedEncoding.
+func (e Encoding) NewDecoder() transform.Transformer {
+	switch e {
+	case EncodingPassthrough:
+		return transform.Nop
+	case EncodingUTF8BOM:
+		return &bomStripper{bom: e.bom()}
+	case EncodingUTF16LE, EncodingUTF16BE, EncodingUTF32LE, EncodingUTF32BE:
+		return transform.Chain(&bomStripper{bom: e.bom()}, e.transformer())
+	default:
+		return &failingTransformer{err: fmt.Errorf("cannot decode %v: %w", e, ErrUnsupportedEncoding)}
+	}
+}
+
+// NewEncoder returns a transform.Transformer that converts UTF-8 to this encoding like
+// a Writer does, emitting the BOM of the encoding ahead of the output. EncodingPassthrough
+// relays the input unchanged, and so does EncodeBytes for it, although a Writer rejects it.
+// For EncodingUnknown, EncodingFallback and values that name no encoding, every Transform
+// call returns an error wrapping ErrUnsupportedEncoding.
+func (e Encoding) NewEncoder() transform.Transformer {
+	if e == EncodingPassthrough {
+		return transform.Nop
+	}
+	if t := e.encoder(true); t != nil {
+		return t
+	}
+	return &failingTransformer{err: fmt.Errorf("cannot encode %v: %w", e, ErrUnsupportedEncoding)}
+}
+
+// utf16Encoding returns the UTF-16 Encoding matching the given byte order.
+func utf16Encoding(e unicode.Endianness) Encoding {
+	if e == unicode.LittleEndian {
+		return EncodingUTF16LE
+	}
+	return EncodingUTF16BE
+}
+
+// bom returns the BOM of the encoding, or nil for encodings that have none.
+func (e Encoding) bom() []byte {
+	switch e {
+	case EncodingUTF16LE:
+		return []byte{0xFF, 0xFE}
+	case EncodingUTF16BE:
+		return []byte{0xFE, 0xFF}
+	case EncodingUTF8BOM:
+		return utf8BOM
+	case EncodingUTF32LE:
+		return []byte{0xFF, 0xFE, 0x00, 0x00}
+	case EncodingUTF32BE:
+		return []byte{0x00, 0x00, 0xFE, 0xFF}
+	default:
+		return nil
+	}
+}
+
+// unitLen returns the size in bytes of the code units of the encoding.
+func (e Encoding) unitLen() int {
+	switch e {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return 2
+	case EncodingUTF32LE, EncodingUTF32BE:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// transformer returns the transform.Transformer that converts input of this
+// encoding to UTF-8 once its BOM has been removed. It returns nil for encodings
+// that are UTF-8 already and need no conversion.
+func (e Encoding) transformer() transform.Transformer {
+	switch e {
+	case EncodingUTF16LE:
+		return &utf16Decoder{endianness: unicode.LittleEndian, replacement: utf8.RuneError}
+	case EncodingUTF16BE:
+		return &utf16Decoder{endianness: unicode.BigEndian, replacement: utf8.RuneError}
+	case EncodingUTF32LE:
+		return utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM).NewDecoder()
+	case EncodingUTF32BE:
+		return utf32.UTF32(utf32.BigEndian, utf32.IgnoreBOM).NewDecoder()
+	default:
+		return nil
+	}
+}
+
+// encoder returns the transform.Transformer that converts UTF-8 to this encoding,
+// emitting a BOM ahead of the output if withBOM is set. It returns nil for
+// encodings that cannot be produced.
+func (e Encoding) encoder(withBOM bool) transform.Transformer {
+	utf16Policy, utf32Policy := unicode.IgnoreBOM, utf32.IgnoreBOM
+	if withBOM {
+		utf16Policy, utf32Policy = unicode.UseBOM, utf32.UseBOM
+	}
+
+	switch e {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, utf16Policy).NewEncoder()
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, utf16Policy).NewEncoder()
+	case EncodingUTF32LE:
+		return utf32.UTF32(utf32.LittleEndian, utf32Policy).NewEncoder()
+	case EncodingUTF32BE:
+		return utf32.UTF32(utf32.BigEndian, utf32Policy).NewEncoder()
+	case EncodingUTF8BOM:
+		// UTF-8 stays as it is and only gains its BOM
+		if withBOM {
+			return &prefixer{prefix: utf8BOM}
+		}
+		return transform.Nop
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/transform"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestEncodingString tests the names of the encodings
+func TestEncodingString(t *testing.T) {
+	tests := []struct {
+		encoding unutf16.Encoding
+		name     string
+		hasBOM   bool
+	}{
+		{encoding: unutf16.EncodingUnknown, name: "unknown", hasBOM: false},
+		{encoding: unutf16.EncodingPassthrough, name: "UTF-8", hasBOM: false},
+		{encoding: unutf16.EncodingUTF16LE, name: "UTF-16LE", hasBOM: true},
+		{encoding: unutf16.EncodingUTF16BE, name: "UTF-16BE", hasBOM: true},
+		{encoding: unutf16.EncodingUTF8BOM, name: "UTF-8-BOM", hasBOM: true},
+		{encoding: unutf16.EncodingUTF32LE, name: "UTF-32LE", hasBOM: true},
+		{encoding: unutf16.EncodingUTF32BE, name: "UTF-32BE", hasBOM: true},
+		{encoding: unutf16.EncodingFallback, name: "fallback", hasBOM: false},
+		{encoding: unutf16.Encoding(42), name: "Encoding(42)", hasBOM: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.name, tt.encoding.String())
+			assert.Equal(t, tt.hasBOM, tt.encoding.HasBOM())
+		})
+	}
+}
+
+// TestEncodingNewDecoder tests that the decoder of an encoding strips its BOM, even when it is split across reads
+func TestEncodingNewDecoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding unutf16.Encoding
+		input    []byte
+		expected string
+	}{
+		// BOM + "hé"
+		{name: "utf16le", encoding: unutf16.EncodingUTF16LE, input: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}, expected: "hé"},
+		{name: "utf16be", encoding: unutf16.EncodingUTF16BE, input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0xE9}, expected: "hé"},
+		{name: "utf32le", encoding: unutf16.EncodingUTF32LE, input: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00}, expected: "h"},
+		{name: "utf32be", encoding: unutf16.EncodingUTF32BE, input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68}, expected: "h"},
+		{name: "utf8 bom", encoding: unutf16.EncodingUTF8BOM, input: []byte("\uFEFFhé"), expected: "hé"},
+		{name: "passthrough", encoding: unutf16.EncodingPassthrough, input: []byte("\uFEFFhé"), expected: "\uFEFFhé"},
+		// Input without a BOM is decoded all the same
+		{name: "utf16le without bom", encoding: unutf16.EncodingUTF16LE, input: []byte{0x68, 0x00, 0xE9, 0x00}, expected: "hé"},
+		{name: "utf8 without bom", encoding: unutf16.EncodingUTF8BOM, input: []byte("hé"), expected: "hé"},
+		// Only the BOM of the encoding itself is stripped
+		{name: "utf16le other bom", encoding: unutf16.EncodingUTF16LE, input: []byte{0xFE, 0xFF, 0x68, 0x00}, expected: "\uFFFEh"},
+		// Only the leading BOM is stripped
+		{name: "interior bom", encoding: unutf16.EncodingUTF16LE, input: []byte{0xFF, 0xFE, 0xFF, 0xFE, 0x68, 0x00}, expected: "\uFEFFh"},
+		{name: "short", encoding: unutf16.EncodingUTF16LE, input: []byte{0xFF}, expected: "\uFFFD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read one byte at a time so the BOM is split across reads
+			reader := transform.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), tt.encoding.NewDecoder())
+
+			output, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestEncodingNewEncoder tests that the encoder of an encoding emits its BOM ahead of the output
+func TestEncodingNewEncoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding unutf16.Encoding
+		expected []byte
+	}{
+		{name: "utf16le", encoding: unutf16.EncodingUTF16LE, expected: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}},
+		{name: "utf16be", encoding: unutf16.EncodingUTF16BE, expected: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0xE9}},
+		{name: "utf32le", encoding: unutf16.EncodingUTF32LE, expected: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0xE9, 0x00, 0x00, 0x00}},
+		{name: "utf32be", encoding: unutf16.EncodingUTF32BE, expected: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0xE9}},
+		{name: "utf8 bom", encoding: unutf16.EncodingUTF8BOM, expected: []byte("\uFEFFhé")},
+		{name: "passthrough", encoding: unutf16.EncodingPassthrough, expected: []byte("hé")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := transform.Bytes(tt.encoding.NewEncoder(), []byte("hé"))
+			if err != nil {
+				t.Fatalf("Error encoding: %v", err)
+			}
+			assert.Equal(t, tt.expected, output)
+
+			// The decoder of the encoding reads it back
+			decoded, _, err := transform.Bytes(tt.encoding.NewDecoder(), output)
+			if err != nil {
+				t.Fatalf("Error decoding: %v", err)
+			}
+			assert.Equal(t, "hé", string(decoded))
+		})
+	}
+}
+
+// TestEncodingUnsupported tests that encodings that cannot be converted fail every Transform call
+func TestEncodingUnsupported(t *testing.T) {
+	for _, encoding := range []unutf16.Encoding{unutf16.EncodingUnknown, unutf16.EncodingFallback, unutf16.Encoding(42)} {
+		t.Run(encoding.String(), func(t *testing.T) {
+			_, _, err := transform.Bytes(encoding.NewDecoder(), []byte("hi"))
+			assert.ErrorIs(t, err, unutf16.ErrUnsupportedEncoding)
+			assert.EqualError(t, err, "cannot decode "+encoding.String()+": unsupported encoding")
+
+			_, _, err = transform.Bytes(encoding.NewEncoder(), []byte("hi"))
+			assert.ErrorIs(t, err, unutf16.ErrUnsupportedEncoding)
+			assert.EqualError(t, err, "cannot encode "+encoding.String()+": unsupported encoding")
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package unutf16
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// NewGzipReader initializes a new Reader like NewReader for gzip-compressed input, such as
+// UTF-16 files that arrive compressed, which it decompresses before detecting the BOM.
+// Unlike NewReader, it reads the gzip header and peeks the BOM right away, so the encoding
+// is known before the first Read. If the gzip header is invalid, the error of gzip.NewReader,
+// such as gzip.ErrHeader, is returned as is; if peeking the BOM fails, a *BOMPeekError is
+// returned. Close closes the gzip reader, but not r.
+func NewGzipReader(r io.Reader, opts ...Option) (*Reader, error) {
+	decompressor, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return openReader(decompressor, opts...)
+}
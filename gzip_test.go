@@ -0,0 +1,107 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestGzipReader tests that compressed input is decompressed before it is decoded
+func TestGzipReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+		encoding unutf16.Encoding
+	}{
+		// UTF-16LE data (BOM + "hi")
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: "hi", encoding: unutf16.EncodingUTF16LE},
+		{name: "passthrough", input: []byte("hi"), expected: "hi", encoding: unutf16.EncodingPassthrough},
+		{name: "empty", input: []byte{}, expected: "", encoding: unutf16.EncodingPassthrough},
+		{
+			name:     "options",
+			input:    utf16be("hi"),
+			opts:     []unutf16.Option{unutf16.WithSniff(4)},
+			expected: "hi",
+			encoding: unutf16.EncodingUTF16BE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader, err := unutf16.NewGzipReader(bytes.NewReader(gzipped(t, tt.input)), tt.opts...)
+			if err != nil {
+				t.Fatalf("Error creating gzip reader: %v", err)
+			}
+			defer utf8Reader.Close()
+
+			// The encoding is detected before the first Read
+			assert.Equal(t, tt.encoding, utf8Reader.DetectedEncoding())
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestGzipReaderHeaderError tests that an invalid gzip header is returned as is
+func TestGzipReaderHeaderError(t *testing.T) {
+	// UTF-16LE data (BOM + "hello") that was not compressed
+	utf8Reader, err := unutf16.NewGzipReader(bytes.NewReader([]byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}))
+
+	assert.ErrorIs(t, err, gzip.ErrHeader)
+	var peekErr *unutf16.BOMPeekError
+	assert.False(t, errors.As(err, &peekErr))
+	assert.Nil(t, utf8Reader)
+}
+
+// TestGzipReaderPeekFailure tests that corrupted compressed data is reported as a BOMPeekError
+func TestGzipReaderPeekFailure(t *testing.T) {
+	compressed := gzipped(t, []byte{0xFF, 0xFE, 0x68, 0x00})
+	// Corrupt the checksum in the trailer
+	compressed[len(compressed)-8] ^= 0xFF
+
+	utf8Reader, err := unutf16.NewGzipReader(bytes.NewReader(compressed))
+
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, gzip.ErrChecksum)
+	assert.Nil(t, utf8Reader)
+}
+
+// TestGzipReaderClose tests that Close closes the gzip reader, but not the compressed source
+func TestGzipReaderClose(t *testing.T) {
+	source := &closeRecorder{Reader: bytes.NewReader(gzipped(t, []byte("hi")))}
+
+	utf8Reader, err := unutf16.NewGzipReader(source)
+	if err != nil {
+		t.Fatalf("Error creating gzip reader: %v", err)
+	}
+
+	assert.NoError(t, utf8Reader.Close())
+	assert.False(t, source.closed)
+}
+
+// gzipped returns data compressed with gzip.
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(data)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		t.Fatalf("Error compressing test data: %v", err)
+	}
+	return buf.Bytes()
+}
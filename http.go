@@ -0,0 +1,36 @@
+package unutf16
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+// DecodeHTTPBody returns an io.Reader that decodes an HTTP response body according to
+// the charset declared by its Content-Type header. For a UTF-16 charset, a BOM at the
+// start of the body takes precedence, and otherwise the byte order the charset implies
+// applies: "utf-16le" and "utf-16be" name theirs, while plain "utf-16" is big endian
+// as RFC 2781 requires. A body without a charset, or with any other charset, is
+// returned unchanged. An error is returned if contentType cannot be parsed.
+func DecodeHTTPBody(contentType string, body io.Reader) (io.Reader, error) {
+	if contentType == "" {
+		return body, nil
+	}
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse content type %q: %w", contentType, err)
+	}
+
+	switch strings.ToLower(params["charset"]) {
+	case "utf-16", "utf-16be":
+		return NewReader(body, WithDefaultEndianness(unicode.BigEndian)), nil
+	case "utf-16le":
+		return NewReader(body, WithDefaultEndianness(unicode.LittleEndian)), nil
+	default:
+		return body, nil
+	}
+}
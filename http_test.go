@@ -0,0 +1,54 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestDecodeHTTPBody tests decoding bodies according to the charset of their content type
+func TestDecodeHTTPBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		input       []byte
+		expected    string
+	}{
+		{name: "utf-16 with bom", contentType: "text/plain; charset=utf-16", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: "hi"},
+		{name: "utf-16 without bom", contentType: "text/plain; charset=UTF-16", input: []byte{0x00, 0x68, 0x00, 0x69}, expected: "hi"},
+		{name: "utf-16le", contentType: "text/html; charset=\"utf-16le\"", input: []byte{0x68, 0x00, 0x69, 0x00}, expected: "hi"},
+		{name: "utf-16be", contentType: "application/json; charset=utf-16be", input: []byte{0x00, 0x68, 0x00, 0x69}, expected: "hi"},
+		{name: "utf-16le with wrong bom", contentType: "text/plain; charset=utf-16le", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}, expected: "hi"},
+		{name: "utf-8", contentType: "text/plain; charset=utf-8", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}, expected: "\xEF\xBB\xBFhi"},
+		{name: "no charset", contentType: "application/octet-stream", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: "\xFF\xFEh\x00"},
+		{name: "no content type", contentType: "", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: "\xFF\xFEh\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := unutf16.DecodeHTTPBody(tt.contentType, bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Error decoding body: %v", err)
+			}
+
+			output, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Error reading from decoded body: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestDecodeHTTPBodyInvalidContentType tests that a malformed content type is reported
+func TestDecodeHTTPBodyInvalidContentType(t *testing.T) {
+	reader, err := unutf16.DecodeHTTPBody("text/plain; charset", bytes.NewReader(nil))
+
+	assert.EqualError(t, err, "cannot parse content type \"text/plain; charset\": mime: invalid media parameter")
+	assert.Nil(t, reader)
+}
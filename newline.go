@@ -0,0 +1,179 @@
+package unutf16
+
+import (
+	"bytes"
+
+	"golang.org/x/text/transform"
+)
+
+// NewlineStyle selects the line endings a Writer produces, and names the line
+// endings a Reader found through DetectedNewlineStyle.
+type NewlineStyle int
+
+const (
+	// NewlineAsIs writes line endings as they are given. This is the default.
+	NewlineAsIs NewlineStyle = iota
+	// NewlineLF converts "\r\n" and bare "\r" line endings to "\n".
+	NewlineLF
+	// NewlineCRLF converts "\n" line endings to "\r\n", which Windows tools expect.
+	// Line endings that already are "\r\n" are kept as they are.
+	NewlineCRLF
+	// NewlineCR converts "\r\n" and "\n" line endings to a bare "\r", as used by
+	// classic Mac OS.
+	NewlineCR
+)
+
+// NewlineUnknown is reported by DetectedNewlineStyle until a newline has been read.
+// It names no line endings, so a Writer cannot produce it.
+const NewlineUnknown NewlineStyle = -1
+
+// transformer returns the transform.Transformer that converts UTF-8 text to the
+// line endings of this style. It returns nil if the text is written as is.
+func (s NewlineStyle) transformer() transform.Transformer {
+	switch s {
+	case NewlineLF:
+		return &newlineNormalizer{newline: '\n'}
+	case NewlineCRLF:
+		return new(crlfExpander)
+	case NewlineCR:
+		return &newlineNormalizer{newline: '\r'}
+	default:
+		return nil
+	}
+}
+
+// newlineNormalizer is a transform.Transformer that converts "\r\n", bare "\r" and
+// "\n" in UTF-8 text to a single newline byte. It remembers a "\r" ending one chunk,
+// so that a "\n" starting the next chunk does not produce a second newline.
+type newlineNormalizer struct {
+	newline  byte             // Byte every line ending is converted to, "\n" or "\r"
+	afterCR  bool             // The last byte seen was a "\r"
+	detector *newlineDetector // Records the first line ending before it is converted, if not nil
+}
+
+// Reset implements the transform.Transformer interface.
+func (n *newlineNormalizer) Reset() {
+	n.afterCR = false
+}
+
+// Transform implements the transform.Transformer interface.
+func (n *newlineNormalizer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if n.detector != nil {
+		defer func() {
+			n.detector.scan(src[:nSrc], atEOF && nSrc == len(src))
+		}()
+	}
+
+	for ; nSrc < len(src); nSrc++ {
+		c := src[nSrc]
+
+		// The "\r" was already written as a newline, so drop the "\n" completing a "\r\n"
+		if n.afterCR && c == '\n' {
+			n.afterCR = false
+			continue
+		}
+
+		if nDst >= len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+
+		n.afterCR = c == '\r'
+		if n.afterCR || c == '\n' {
+			c = n.newline
+		}
+		dst[nDst] = c
+		nDst++
+	}
+	return nDst, nSrc, nil
+}
+
+// crlfExpander is a transform.Transformer that converts "\n" in UTF-8 text to "\r\n".
+// It remembers a "\r" ending one chunk, so that a "\r\n" split across chunks is not
+// converted into "\r\r\n".
+type crlfExpander struct {
+	afterCR bool // The last byte seen was a "\r"
+}
+
+// Reset implements the transform.Transformer interface.
+func (e *crlfExpander) Reset() {
+	e.afterCR = false
+}
+
+// Transform implements the transform.Transformer interface.
+func (e *crlfExpander) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for ; nSrc < len(src); nSrc++ {
+		c := src[nSrc]
+
+		// A "\n" on its own needs a "\r" ahead of it
+		if c == '\n' && !e.afterCR {
+			if nDst+2 > len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = '\r'
+			nDst++
+		} else if nDst >= len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+
+		e.afterCR = c == '\r'
+		dst[nDst] = c
+		nDst++
+	}
+	return nDst, nSrc, nil
+}
+
+// newlineDetector records the line ending of the first newline in UTF-8 text that is
+// shown to it piece by piece. A "\r" ending a piece is only settled by the byte after
+// it, or by the end of the text.
+type newlineDetector struct {
+	style   NewlineStyle // Line ending of the first newline, once found is set
+	found   bool         // The first newline has been seen
+	afterCR bool         // The text seen so far ends in a "\r" that may start a "\r\n"
+}
+
+// scan looks for the first newline in the next piece b of the text, where atEOF
+// reports whether b is the last piece.
+func (d *newlineDetector) scan(b []byte, atEOF bool) {
+	if d.found {
+		return
+	}
+
+	if d.afterCR {
+		switch {
+		case len(b) > 0 && b[0] == '\n':
+			d.record(NewlineCRLF)
+		case len(b) > 0 || atEOF:
+			d.record(NewlineCR)
+		}
+		return
+	}
+
+	// Two searches for a single byte are much faster than one for either of them
+	lf := bytes.IndexByte(b, '\n')
+	end := len(b)
+	if lf >= 0 {
+		end = lf
+	}
+	if cr := bytes.IndexByte(b[:end], '\r'); cr >= 0 {
+		d.afterCR = true
+		d.scan(b[cr+1:], atEOF)
+		return
+	}
+	if lf >= 0 {
+		d.record(NewlineLF)
+	}
+}
+
+// record settles the line ending of the first newline.
+func (d *newlineDetector) record(style NewlineStyle) {
+	d.style, d.found = style, true
+}
+
+// detected returns the line ending of the first newline, or NewlineUnknown if none
+// has been seen yet.
+func (d *newlineDetector) detected() NewlineStyle {
+	if !d.found {
+		return NewlineUnknown
+	}
+	return d.style
+}
@@ -0,0 +1,179 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestNormalizeNewlines tests conversion of CRLF and CR line endings to LF
+func TestNormalizeNewlines(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{name: "utf8", input: []byte("a\r\nb\rc\nd\r\r\ne\r"), expected: "a\nb\nc\nd\n\ne\n"},
+		// UTF-16LE data (BOM + "a\r\nb\rc")
+		{
+			name:     "utf16le",
+			input:    []byte{0xFF, 0xFE, 0x61, 0x00, 0x0D, 0x00, 0x0A, 0x00, 0x62, 0x00, 0x0D, 0x00, 0x63, 0x00},
+			expected: "a\nb\nc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), unutf16.WithNormalizeNewlines())
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestNormalizeNewlinesSplitCRLF tests that a CRLF split across reads produces a single newline
+func TestNormalizeNewlinesSplitCRLF(t *testing.T) {
+	input := []byte("a\r\nb\r\n")
+
+	utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(input)), unutf16.WithNormalizeNewlines())
+
+	// Read one byte at a time so the "\r" and "\n" end up in separate reads
+	var output []byte
+	buffer := make([]byte, 1)
+	for {
+		n, err := utf8Reader.Read(buffer)
+		output = append(output, buffer[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+	}
+
+	assert.Equal(t, "a\nb\n", string(output))
+}
+
+// TestDetectedNewlineStyle tests that the line ending of the first newline in the output is reported
+func TestDetectedNewlineStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected unutf16.NewlineStyle
+	}{
+		{name: "lf", input: []byte("a\nb\r\n"), expected: unutf16.NewlineLF},
+		{name: "crlf", input: []byte("a\r\nb\n"), expected: unutf16.NewlineCRLF},
+		{name: "cr", input: []byte("a\rb\r\n"), expected: unutf16.NewlineCR},
+		{name: "cr at end", input: []byte("a\r"), expected: unutf16.NewlineCR},
+		{name: "none", input: []byte("abc"), expected: unutf16.NewlineUnknown},
+		{name: "empty", input: []byte{}, expected: unutf16.NewlineUnknown},
+		// UTF-16LE data (BOM + "a\r\nb")
+		{
+			name:     "utf16le",
+			input:    []byte{0xFF, 0xFE, 0x61, 0x00, 0x0D, 0x00, 0x0A, 0x00, 0x62, 0x00},
+			expected: unutf16.NewlineCRLF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" read", func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), unutf16.WithNewlineDetection())
+			assert.Equal(t, unutf16.NewlineUnknown, utf8Reader.DetectedNewlineStyle())
+
+			// Read one byte at a time so the "\r" and "\n" end up in separate reads
+			buffer := make([]byte, 1)
+			for {
+				_, err := utf8Reader.Read(buffer)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Error reading from UTF8 reader: %v", err)
+				}
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.DetectedNewlineStyle())
+		})
+
+		t.Run(tt.name+" write to", func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), unutf16.WithNewlineDetection())
+
+			_, err := utf8Reader.WriteTo(io.Discard)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.DetectedNewlineStyle())
+		})
+
+		t.Run(tt.name+" normalized", func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), unutf16.WithNewlineDetection(), unutf16.WithNormalizeNewlines())
+
+			_, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			// The line ending is recorded before it is converted to "\n"
+			assert.Equal(t, tt.expected, utf8Reader.DetectedNewlineStyle())
+		})
+	}
+}
+
+// TestDetectedNewlineStyleDisabled tests that the newline is not looked for without WithNewlineDetection
+func TestDetectedNewlineStyleDisabled(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("a\r\nb")))
+
+	_, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, unutf16.NewlineUnknown, utf8Reader.DetectedNewlineStyle())
+}
+
+// TestDetectedNewlineStylePending tests that a "\r" is only settled by the byte after it
+func TestDetectedNewlineStylePending(t *testing.T) {
+	utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader([]byte("a\r\n"))), unutf16.WithNewlineDetection())
+
+	buffer := make([]byte, 2)
+	_, err := io.ReadFull(utf8Reader, buffer)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, unutf16.NewlineUnknown, utf8Reader.DetectedNewlineStyle())
+
+	_, err = io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, unutf16.NewlineCRLF, utf8Reader.DetectedNewlineStyle())
+}
+
+// TestDetectedNewlineStyleReadRune tests that bytes ReadRune gives back are not inspected twice
+func TestDetectedNewlineStyleReadRune(t *testing.T) {
+	// An invalid lead byte followed by a "\r\n", so ReadRune reads ahead into the "\r\n"
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("\xE2\r\n")), unutf16.WithNewlineDetection())
+
+	for {
+		_, _, err := utf8Reader.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+	}
+
+	assert.Equal(t, unutf16.NewlineCRLF, utf8Reader.DetectedNewlineStyle())
+}
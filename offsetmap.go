@@ -0,0 +1,70 @@
+package unutf16
+
+import (
+	"sort"
+)
+
+// defaultCheckpointInterval is the number of runes between the checkpoints of an
+// OffsetMap whose Interval is zero.
+const defaultCheckpointInterval = 1024
+
+// Checkpoint ties a position in the input of a Reader to the position in its output
+// that was decoded from it. Like the ProducedOffset of a DecodeError, the output offset
+// does not account for post-processing such as WithNormalizeNewlines or WithKeepBOM.
+type Checkpoint struct {
+	Input  int64 // Offset within the input, counting the BOM
+	Output int64 // Offset within the decoded output
+}
+
+// OffsetMap records checkpoints that map decoded output back to the input it came from,
+// such as to locate a position in the decoded view of an editor within the UTF-16 source.
+// It is filled by a Reader created with WithOffsetMap while decoding, starting over with
+// the first Read after every Reset. An OffsetMap is not safe for concurrent use, so it
+// should only be inspected while the Reader is not being read from.
+type OffsetMap struct {
+	// Interval is the number of runes between checkpoints, 1024 if zero. Memory use grows
+	// by one Checkpoint per interval of decoded runes.
+	Interval int
+
+	checkpoints []Checkpoint
+}
+
+// Checkpoints returns the checkpoints recorded so far, in increasing order of both offsets.
+// The first checkpoint is the start of the output, which follows the BOM. Checkpoints are
+// recorded for the built-in UTF-16 decoders; for input that is passed through, which only
+// differs from the output by the BOM, and for any other encoding, there is just the first one.
+func (m *OffsetMap) Checkpoints() []Checkpoint {
+	return m.checkpoints
+}
+
+// Lookup returns the last checkpoint at or before the output offset, from which the input
+// can be decoded again to find the exact position. It returns the zero Checkpoint if no
+// checkpoint has been recorded yet.
+func (m *OffsetMap) Lookup(output int64) Checkpoint {
+	i := sort.Search(len(m.checkpoints), func(i int) bool {
+		return m.checkpoints[i].Output > output
+	})
+	if i == 0 {
+		return Checkpoint{}
+	}
+	return m.checkpoints[i-1]
+}
+
+// interval returns the number of runes between checkpoints.
+func (m *OffsetMap) interval() int64 {
+	if m.Interval > 0 {
+		return int64(m.Interval)
+	}
+	return defaultCheckpointInterval
+}
+
+// restart discards the recorded checkpoints, keeping their memory, and records the
+// start of the output following a BOM of bomLen bytes.
+func (m *OffsetMap) restart(bomLen int) {
+	m.checkpoints = append(m.checkpoints[:0], Checkpoint{Input: int64(bomLen)})
+}
+
+// record adds a checkpoint.
+func (m *OffsetMap) record(input, output int64) {
+	m.checkpoints = append(m.checkpoints, Checkpoint{Input: input, Output: output})
+}
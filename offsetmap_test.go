@@ -0,0 +1,83 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestOffsetMap tests that checkpoints are recorded every interval of runes
+func TestOffsetMap(t *testing.T) {
+	// UTF-16LE data (BOM + "hé👋" + "a€b"), whose runes take 1, 2, 4, 1, 3 and 1 bytes in UTF-8
+	utf16leData := []byte{
+		0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0x3D, 0xD8, 0x4B, 0xDC,
+		0x61, 0x00, 0xAC, 0x20, 0x62, 0x00,
+	}
+
+	offsets := &unutf16.OffsetMap{Interval: 2}
+	// Read one byte at a time so that runes end up split across transforms
+	utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(utf16leData)), unutf16.WithOffsetMap(offsets))
+	_, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	expected := []unutf16.Checkpoint{
+		{Input: 2, Output: 0},
+		{Input: 6, Output: 3},
+		{Input: 12, Output: 8},
+		{Input: 16, Output: 12},
+	}
+	assert.Equal(t, expected, offsets.Checkpoints())
+
+	assert.Equal(t, unutf16.Checkpoint{Input: 2, Output: 0}, offsets.Lookup(2))
+	assert.Equal(t, unutf16.Checkpoint{Input: 6, Output: 3}, offsets.Lookup(3))
+	assert.Equal(t, unutf16.Checkpoint{Input: 12, Output: 8}, offsets.Lookup(11))
+	assert.Equal(t, unutf16.Checkpoint{Input: 16, Output: 12}, offsets.Lookup(100))
+}
+
+// TestOffsetMapReset tests that a reset Reader maps its new source from the start
+func TestOffsetMapReset(t *testing.T) {
+	offsets := &unutf16.OffsetMap{Interval: 1}
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}), unutf16.WithOffsetMap(offsets))
+	_, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	utf8Reader.Reset(bytes.NewReader([]byte{0xFE, 0xFF, 0x00, 0x68}))
+	_, err = io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, []unutf16.Checkpoint{{Input: 2, Output: 0}, {Input: 4, Output: 1}}, offsets.Checkpoints())
+}
+
+// TestOffsetMapPassthrough tests that input that is passed through has just the checkpoint following the BOM
+func TestOffsetMapPassthrough(t *testing.T) {
+	offsets := &unutf16.OffsetMap{Interval: 1}
+	_, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte("\xEF\xBB\xBFhello")), unutf16.WithOffsetMap(offsets)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, []unutf16.Checkpoint{{Input: 3, Output: 0}}, offsets.Checkpoints())
+	assert.Equal(t, unutf16.Checkpoint{}, new(unutf16.OffsetMap).Lookup(0))
+}
+
+// TestOffsetMapConfigError tests that a missing offset map or a negative interval is rejected
+func TestOffsetMapConfigError(t *testing.T) {
+	for _, offsets := range []*unutf16.OffsetMap{nil, {Interval: -1}} {
+		_, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte("hi")), unutf16.WithOffsetMap(offsets)))
+
+		var configErr *unutf16.ConfigError
+		assert.True(t, errors.As(err, &configErr))
+	}
+}
@@ -0,0 +1,669 @@
+package unutf16
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Option configures a Reader created by NewReader.
+type Option func(*config)
+
+// config holds the settings applied to a Reader through its options.
+// The zero value represents the default behavior of NewReader.
+type config struct {
+	// defaultEndianness is used to decode input without a BOM as UTF-16
+	// when hasDefaultEndianness is set.
+	defaultEndianness    unicode.Endianness
+	hasDefaultEndianness bool
+	// forced is used to decode all input, without looking for a BOM, unless it is
+	// EncodingUnknown; it is set by NewReaderForced and NewRangeReader.
+	forced Encoding
+	// strict makes malformed UTF-16 input an error instead of replacing it.
+	strict bool
+	// replacement is substituted for malformed UTF-16 input when hasReplacement is set.
+	replacement    rune
+	hasReplacement bool
+	// surrogates determines how unpaired surrogates in UTF-16 input are decoded.
+	surrogates SurrogatePolicy
+	// rejectOddLength makes UTF-16 input ending on an odd byte boundary an error.
+	rejectOddLength bool
+	// sniffLen is the number of bytes inspected by SniffEncoding when input has no BOM.
+	sniffLen int
+	// normalizeNewlines converts "\r\n" and "\r" in the decoded output to "\n".
+	normalizeNewlines bool
+	// requireNonEmpty makes input without a single byte an error.
+	requireNonEmpty bool
+	// validateOutput checks that decoded output is valid UTF-8.
+	validateOutput bool
+	// maxReplacementRatio is the largest share of replacement characters in decoded
+	// output tolerated when hasMaxReplacementRatio is set.
+	maxReplacementRatio    float64
+	hasMaxReplacementRatio bool
+	// replacementString is substituted for every U+FFFD in the output when
+	// hasReplacementString is set.
+	replacementString    string
+	hasReplacementString bool
+	// assumeBEOnLeadingNUL decodes input without a BOM that starts with a null byte as UTF-16BE.
+	assumeBEOnLeadingNUL bool
+	// autoCorrectEndianness overrules a UTF-16 BOM that the content contradicts.
+	autoCorrectEndianness bool
+	// flushEager stops peeking as soon as the encoding is certain.
+	flushEager bool
+	// keepBOM re-emits a stripped BOM as a UTF-8 BOM ahead of the output.
+	keepBOM bool
+	// strictBOM rejects input starting with the BOM of an unsupported encoding.
+	strictBOM bool
+	// requireBOM rejects input that does not start with a supported BOM.
+	requireBOM bool
+	// decoders override how input of an encoding is decoded.
+	decoders map[Encoding]func() transform.Transformer
+	// fallback decodes input without a BOM instead of passing it through.
+	fallback encoding.Encoding
+	// maxPeek is the largest number of bytes buffered for detection, if not zero.
+	maxPeek int
+	// peekTimeout bounds how long the peek may take, if not zero.
+	peekTimeout time.Duration
+	// maxEmptyReads is the number of consecutive empty reads of the source tolerated, if not zero.
+	maxEmptyReads int
+	// hint is the encoding the input is labeled with, such as by a Content-Type charset.
+	hint encoding.Encoding
+	// bomPriority decides whether a BOM or the hint wins when they disagree.
+	bomPriority BOMPriority
+	// bufferSize is the size of the buffer WriteTo copies through, if not zero.
+	bufferSize int
+	// progress is called with running totals of bytes consumed and produced.
+	progress func(consumed, produced int64)
+	// offsets records checkpoints mapping the output back to the input, if not nil.
+	offsets *OffsetMap
+	// logger is told about the decisions made while detecting the encoding.
+	logger func(msg string)
+	// stats counts the replacement characters and newlines in the output for Stats.
+	stats bool
+	// detectNewlines records the line ending of the first newline for DetectedNewlineStyle.
+	detectNewlines bool
+
+	// ctx cancels reads when it is done; it is set by NewReaderContext.
+	ctx context.Context
+	// truncatedTail is set by the UTF-16 decoders when the input ends in an unpaired
+	// high surrogate; it points into the Reader, which sets it up before decoding.
+	truncatedTail *bool
+	// anomaly is set when the content following a UTF-16 BOM looks byte-swapped; it
+	// points into the Reader, which sets it up before detecting the encoding.
+	anomaly *bool
+	// newlines records the line ending of the first newline ahead of WithNormalizeNewlines;
+	// it points into the Reader, which sets it up before decoding.
+	newlines *newlineDetector
+
+	// err records why the options cannot be honored; it is reported by the first Read.
+	err error
+}
+
+// newConfig applies the given options on top of the default configuration
+// and validates the result.
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if err := c.validate(); err != nil {
+		c.fail(err)
+	}
+	return c
+}
+
+// validate checks that the combination of options can be honored.
+func (c *config) validate() error {
+	if c.hasReplacement && !utf8.ValidRune(c.replacement) {
+		return &ConfigError{Reason: fmt.Sprintf("replacement %U is not a valid rune", c.replacement)}
+	}
+	if c.sniffLen < 0 {
+		return &ConfigError{Reason: fmt.Sprintf("sniff length %d is negative", c.sniffLen)}
+	}
+	if c.fallback != nil && c.hasDefaultEndianness {
+		return &ConfigError{Reason: "WithFallbackEncoding and WithDefaultEndianness are mutually exclusive"}
+	}
+	if c.surrogates < SurrogateReplace || c.surrogates > SurrogatePassthrough {
+		return &ConfigError{Reason: fmt.Sprintf("surrogate policy %d is unknown", c.surrogates)}
+	}
+	if c.maxPeek > 0 && c.peekLen() > c.maxPeek {
+		return &ConfigError{Reason: fmt.Sprintf("detection needs %d bytes, more than the maximum peek of %d", c.peekLen(), c.maxPeek)}
+	}
+	if c.hint != nil && c.fallback != nil {
+		return &ConfigError{Reason: "WithCharsetHint and WithFallbackEncoding are mutually exclusive"}
+	}
+	if c.hint != nil && c.hasDefaultEndianness {
+		return &ConfigError{Reason: "WithCharsetHint and WithDefaultEndianness are mutually exclusive"}
+	}
+	if c.bomPriority != BOMWins && c.bomPriority != HintWins {
+		return &ConfigError{Reason: fmt.Sprintf("BOM priority %d is unknown", c.bomPriority)}
+	}
+	if c.strict && c.hasReplacement {
+		return &ConfigError{Reason: "WithStrict and WithReplacement are mutually exclusive"}
+	}
+	if c.strict && c.hasReplacementString {
+		return &ConfigError{Reason: "WithStrict and WithReplacementString are mutually exclusive"}
+	}
+	return nil
+}
+
+// WithDefaultEndianness makes the Reader treat input without a BOM as UTF-16
+// with the given byte order instead of passing it through as UTF-8.
+// A BOM present at the start of the input always takes precedence over this default.
+func WithDefaultEndianness(e unicode.Endianness) Option {
+	return func(c *config) {
+		c.defaultEndianness = e
+		c.hasDefaultEndianness = true
+	}
+}
+
+// WithStrict makes the Reader return a *DecodeError on the first malformed
+// UTF-16 sequence, such as an unpaired surrogate or a dangling trailing byte,
+// instead of replacing it with U+FFFD. It has no effect on other encodings.
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithReplacement makes the Reader substitute r instead of U+FFFD for malformed
+// UTF-16 sequences, such as an unpaired surrogate or a dangling trailing byte.
+// It cannot be combined with WithStrict.
+func WithReplacement(r rune) Option {
+	return func(c *config) {
+		c.replacement = r
+		c.hasReplacement = true
+	}
+}
+
+// WithReplacementString makes the Reader substitute s for every U+FFFD in its output,
+// such as a marker like "[?]" that is easy to grep for wherever decoding failed. Unlike
+// WithReplacement, this post-processes the decoded output, so it also applies to U+FFFD that
+// was part of the input already, and to input that is passed through otherwise; a rune passed
+// to WithReplacement is not substituted. Since the output then no longer contains the U+FFFD,
+// Stats does not count it, while WithMaxReplacementRatio still does. An s that is not valid
+// UTF-8 makes every Read fail with a *ConfigError, and so does combining it with WithStrict.
+func WithReplacementString(s string) Option {
+	return func(c *config) {
+		if !utf8.ValidString(s) {
+			c.fail(&ConfigError{Reason: fmt.Sprintf("replacement string %q is not valid UTF-8", s)})
+			return
+		}
+		c.replacementString = s
+		c.hasReplacementString = true
+	}
+}
+
+// WithSurrogatePolicy determines how the Reader decodes unpaired surrogates in UTF-16
+// input, which indicate a corrupted stream, independently of other malformed input.
+// With WithStrict, SurrogateReplace is overridden and unpaired surrogates are reported
+// like any other malformed input; pair WithStrict with SurrogatePassthrough to only reject
+// other malformed input, such as a dangling trailing byte, while keeping surrogates as WTF-8.
+func WithSurrogatePolicy(policy SurrogatePolicy) Option {
+	return func(c *config) {
+		c.surrogates = policy
+	}
+}
+
+// WithRejectOddLength makes the Reader return ErrOddLength when UTF-16 input
+// ends on an odd byte boundary, which indicates a truncated stream, instead of
+// replacing the dangling byte. Input that is not decoded as UTF-16 is unaffected.
+func WithRejectOddLength() Option {
+	return func(c *config) {
+		c.rejectOddLength = true
+	}
+}
+
+// WithSniff makes the Reader guess the encoding of input without a BOM using
+// SniffEncoding on a sample of up to n bytes, and decode it as UTF-16 if the
+// guess says so. The sample is capped at 64 KiB so a large input is never buffered
+// as a whole. Note that the first Read blocks until the sample has been read or
+// the source is exhausted. WithDefaultEndianness only applies if the guess is
+// inconclusive, and a BOM present at the start of the input always takes precedence.
+func WithSniff(n int) Option {
+	return func(c *config) {
+		c.sniffLen = min(n, maxSniffLen)
+	}
+}
+
+// WithAssumeBEOnLeadingNUL makes the Reader decode input without a BOM as UTF-16BE if its
+// first byte is 0x00, as it is for UTF-16BE text starting with an ASCII character, rather than
+// passing it through with a stray NUL. This is a heuristic that can misfire: binary data or
+// UTF-32BE without a BOM starting with a null byte is decoded as UTF-16BE as well. It applies
+// after WithSniff found the sample inconclusive, and takes precedence over WithDefaultEndianness
+// and WithFallbackEncoding, while a BOM or WithCharsetHint always takes precedence over it.
+func WithAssumeBEOnLeadingNUL() Option {
+	return func(c *config) {
+		c.assumeBEOnLeadingNUL = true
+	}
+}
+
+// WithNormalizeNewlines makes the Reader convert "\r\n" and bare "\r" line endings
+// in the decoded output to "\n". This also applies to input that is passed through.
+func WithNormalizeNewlines() Option {
+	return func(c *config) {
+		c.normalizeNewlines = true
+	}
+}
+
+// WithRequireNonEmpty makes the Reader return ErrEmptyInput instead of io.EOF when the
+// source yields no bytes at all, which usually signals a truncated download. Without this
+// option, empty input produces an empty stream. A BOM on its own counts as input.
+func WithRequireNonEmpty() Option {
+	return func(c *config) {
+		c.requireNonEmpty = true
+	}
+}
+
+// WithValidateOutput makes the Reader check that the decoded output is valid UTF-8, and
+// return a *DecodeError wrapping ErrInvalidOutput if it is not, as a safeguard against bugs
+// in a decoder, such as one passed to WithDecoderFor. Input that is passed through without
+// conversion is not checked, since it is not guaranteed to be valid UTF-8 to begin with.
+// The check is skipped unless this option is given, to avoid its overhead.
+func WithValidateOutput() Option {
+	return func(c *config) {
+		c.validateOutput = true
+	}
+}
+
+// WithAutoCorrectEndianness makes the Reader check the byte order of a UTF-16 BOM against
+// the null bytes in a sample of up to 256 bytes following it, using SniffEncodingConfidence.
+// If the sample strongly suggests the other byte order, as in files from exporters that
+// write the wrong BOM, the Reader decodes the input in that byte order instead and reports
+// a warning to the logger of WithLogger. Samples shorter than 8 code units are never
+// overruled. The first Read blocks until the sample has been read or the source is exhausted.
+func WithAutoCorrectEndianness() Option {
+	return func(c *config) {
+		c.autoCorrectEndianness = true
+	}
+}
+
+// WithFlushEager makes the Reader hand out output as early as possible, for interactive use
+// such as decoding a UTF-16 console stream line by line. Decoded output is always handed out
+// as soon as a read of the source completes a code point, but the first Read waits for up to
+// 4 bytes to detect a BOM. With this option it stops waiting as soon as the bytes read so far
+// cannot be the start of a BOM anymore. Only detection is affected, so throughput on bulk
+// input does not suffer beyond a first Read that may return less. Output is still held back
+// while it could be part of a BOM or a surrogate pair, and WithSniff still waits for its
+// whole sample.
+func WithFlushEager() Option {
+	return func(c *config) {
+		c.flushEager = true
+	}
+}
+
+// WithKeepBOM makes the Reader keep the BOM of its input, which is otherwise stripped.
+// Whatever BOM was detected is emitted as the UTF-8 BOM "\xEF\xBB\xBF" ahead of the
+// decoded output. This only makes sense when the consumer of the output expects a BOM,
+// such as when re-emitting a file that has to keep its original BOM.
+func WithKeepBOM() Option {
+	return func(c *config) {
+		c.keepBOM = true
+	}
+}
+
+// WithStrictBOM makes the Reader return an *UnsupportedBOMError for input that starts
+// with the BOM of an encoding it cannot decode, such as GB18030, UTF-7 or UTF-EBCDIC,
+// instead of passing it through as if it were UTF-8.
+func WithStrictBOM() Option {
+	return func(c *config) {
+		c.strictBOM = true
+	}
+}
+
+// WithRequireBOM makes the Reader return ErrMissingBOM for input that does not start with
+// the BOM of an encoding it can decode, rather than guessing how to read it. Any UTF-8,
+// UTF-16 or UTF-32 BOM is accepted. Options that only apply to input without a BOM,
+// such as WithSniff, WithDefaultEndianness or WithFallbackEncoding, have no effect.
+func WithRequireBOM() Option {
+	return func(c *config) {
+		c.requireBOM = true
+	}
+}
+
+// WithFallbackEncoding makes the Reader decode input without a BOM with the given
+// encoding, e.g. charmap.Windows1252, instead of passing it through as UTF-8.
+// A BOM always takes precedence, and so does a conclusive guess when combined with WithSniff.
+// It cannot be combined with WithDefaultEndianness.
+func WithFallbackEncoding(e encoding.Encoding) Option {
+	return func(c *config) {
+		c.fallback = e
+	}
+}
+
+// WithDecoderFor makes the Reader decode input detected as e with the transformer returned
+// by factory instead of the built-in decoder, such as to support an encoding x/text does not
+// provide. The transformer receives the input following the BOM, if any, and has to produce
+// UTF-8. Post-processing such as WithNormalizeNewlines still applies to its output.
+// Strict mode, replacement and the other decoding options only apply to built-in decoders.
+// Overriding EncodingPassthrough decodes input without a BOM instead of passing it through.
+func WithDecoderFor(e Encoding, factory func() transform.Transformer) Option {
+	return func(c *config) {
+		if c.decoders == nil {
+			c.decoders = make(map[Encoding]func() transform.Transformer)
+		}
+		c.decoders[e] = factory
+	}
+}
+
+// WithCharsetHint tells the Reader which encoding its input is labeled with, such as the
+// charset of a Content-Type header, e.g. unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+// or charmap.ISO8859_1. Input without a BOM is decoded with the hint, which takes precedence
+// over WithSniff and is reported as EncodingFallback. If the input starts with a BOM that
+// disagrees with the hint, WithBOMPriority decides which one wins. It cannot be combined
+// with WithFallbackEncoding or WithDefaultEndianness.
+func WithCharsetHint(e encoding.Encoding) Option {
+	return func(c *config) {
+		c.hint = e
+	}
+}
+
+// WithBOMPriority decides whether a BOM or the encoding passed to WithCharsetHint wins when
+// they disagree, which matters for mislabeled content. With BOMWins, the default, the BOM is
+// stripped and decides the encoding. With HintWins, the BOM is not detected at all and the
+// whole input, including the bytes of the BOM, is decoded with the hint.
+func WithBOMPriority(priority BOMPriority) Option {
+	return func(c *config) {
+		c.bomPriority = priority
+	}
+}
+
+// WithBufferSize sets the size of the buffer that decoded data is copied through,
+// such as when the Reader is drained with io.Copy. Larger buffers reduce the
+// per-call overhead for large inputs. The size has to be positive.
+func WithBufferSize(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			c.fail(&ConfigError{Reason: fmt.Sprintf("buffer size %d is not positive", n)})
+			return
+		}
+		c.bufferSize = n
+	}
+}
+
+// WithMaxReplacementRatio makes the Reader fail with an error wrapping ErrTooManyReplacements
+// once more than the fraction f, between 0 and 1, of the runes it decoded are replacement
+// characters, which signals that the input was not really in the detected encoding, such as
+// binary data that happens to start with a BOM. The ratio is only judged once a sample of
+// 64 runes has been decoded, or at the end of shorter input. Input that is passed through
+// as UTF-8 is not checked.
+func WithMaxReplacementRatio(f float64) Option {
+	return func(c *config) {
+		if !(f >= 0 && f <= 1) {
+			c.fail(&ConfigError{Reason: fmt.Sprintf("replacement ratio %v is not between 0 and 1", f)})
+			return
+		}
+		c.maxReplacementRatio = f
+		c.hasMaxReplacementRatio = true
+	}
+}
+
+// WithMaxPeek caps the number of bytes the Reader buffers to detect the encoding before
+// committing to a decoder at n, which keeps memory use predictable on constrained systems.
+// Detecting a BOM takes 4 bytes, and WithSniff takes as many bytes as its sample, so
+// a limit below what the other options need makes every Read fail with a *ConfigError.
+// The size has to be positive.
+func WithMaxPeek(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			c.fail(&ConfigError{Reason: fmt.Sprintf("maximum peek %d is not positive", n)})
+			return
+		}
+		c.maxPeek = n
+	}
+}
+
+// WithPeekTimeout bounds how long the first Read waits for the BOM of a source that may
+// block, as a lighter alternative to NewReaderContext. If the peek does not complete within d,
+// that Read returns a *BOMPeekError wrapping context.DeadlineExceeded, and all further reads
+// fail. A source that implements io.Closer is closed to release the abandoned read, and one that
+// only has a SetReadDeadline method gets a deadline in the past. For any other source the
+// goroutine waiting on the read is leaked until that read returns, which may be never.
+// The duration has to be positive.
+func WithPeekTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d <= 0 {
+			c.fail(&ConfigError{Reason: fmt.Sprintf("peek timeout %v is not positive", d)})
+			return
+		}
+		c.peekTimeout = d
+	}
+}
+
+// WithMaxEmptyReads sets how many consecutive reads of the source that return no data
+// and no error the Reader tolerates before it gives up with io.ErrNoProgress, rather than
+// waiting forever for a misbehaving source. Without this option, 100 such reads are
+// tolerated. Once the BOM has been peeked, input that is passed through is read straight
+// from the source, so empty reads are handed to the caller as they are. The number has
+// to be positive.
+func WithMaxEmptyReads(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			c.fail(&ConfigError{Reason: fmt.Sprintf("maximum empty reads %d is not positive", n)})
+			return
+		}
+		c.maxEmptyReads = n
+	}
+}
+
+// WithProgress makes the Reader call fn after every Read, and after every chunk
+// WriteTo writes, with the running totals of bytes consumed from the source and
+// bytes of UTF-8 output produced. The consumed total includes the peeked BOM
+// window, so it may run ahead of the produced output. fn is called synchronously
+// from the reading goroutine, without holding any locks.
+func WithProgress(fn func(consumed, produced int64)) Option {
+	return func(c *config) {
+		c.progress = fn
+	}
+}
+
+// WithOffsetMap makes the Reader record checkpoints in m while decoding, which map
+// positions in the decoded output back to the input, every m.Interval runes.
+func WithOffsetMap(m *OffsetMap) Option {
+	return func(c *config) {
+		if m == nil || m.Interval < 0 {
+			c.fail(&ConfigError{Reason: "offset map is nil or has a negative interval"})
+			return
+		}
+		c.offsets = m
+	}
+}
+
+// WithLogger makes the Reader call fn with a message for every decision it makes while
+// detecting the encoding, such as "detected UTF-16LE via BOM" or "no BOM found, using
+// passthrough", which helps to find out why input was decoded the way it was. The messages
+// are meant for humans and may change. fn is called synchronously from the reading goroutine.
+func WithLogger(fn func(msg string)) Option {
+	return func(c *config) {
+		c.logger = fn
+	}
+}
+
+// WithStats makes the Reader count the replacement characters and line endings in its
+// output, as well as the bytes consumed from the source, for Stats to report. Without
+// this option only the bytes of output produced are counted, since every chunk of output
+// has to be scanned for the others.
+func WithStats() Option {
+	return func(c *config) {
+		c.stats = true
+	}
+}
+
+// WithNewlineDetection makes the Reader record the line ending of the first newline in its
+// output for DetectedNewlineStyle to report. Without this option the output is not scanned
+// for it, so that input passed through is handed on untouched, and DetectedNewlineStyle
+// always returns NewlineUnknown. With WithNormalizeNewlines, the line ending is recorded
+// before it is converted.
+func WithNewlineDetection() Option {
+	return func(c *config) {
+		c.detectNewlines = true
+	}
+}
+
+// log passes msg to the logger, if there is one.
+func (c *config) log(msg string) {
+	if c.logger != nil {
+		c.logger(msg)
+	}
+}
+
+// logf passes a message about e, formatted with format, to the logger, if there is one.
+// It takes e rather than arbitrary arguments, so that nothing is formatted or
+// allocated without a logger.
+func (c *config) logf(format string, e Encoding) {
+	if c.logger != nil {
+		c.logger(fmt.Sprintf(format, e))
+	}
+}
+
+// fail records that the options cannot be honored, keeping the first reason given.
+func (c *config) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// bufferLen returns the size of the buffer decoded data is copied through.
+func (c *config) bufferLen() int {
+	if c.bufferSize > 0 {
+		return c.bufferSize
+	}
+	return copyBufferSize
+}
+
+// emptyReadsLimit returns the number of consecutive empty reads of the source tolerated.
+func (c *config) emptyReadsLimit() int {
+	if c.maxEmptyReads > 0 {
+		return c.maxEmptyReads
+	}
+	return defaultMaxEmptyReads
+}
+
+// contextErr returns the error of the configured context, or nil if there is none
+// or it is not done yet.
+func (c *config) contextErr() error {
+	if c.ctx == nil {
+		return nil
+	}
+	return c.ctx.Err()
+}
+
+// peekLen returns the number of bytes the Reader inspects before committing to a decoder.
+func (c *config) peekLen() int {
+	if c.forced != EncodingUnknown {
+		// A single byte is enough to tell whether there is any input
+		if c.requireNonEmpty {
+			return 1
+		}
+		return 0
+	}
+	n := max(maxBOMLen, c.sniffLen)
+	if c.autoCorrectEndianness {
+		// The sample is a nice-to-have, so it is cut short rather than rejected by WithMaxPeek
+		sample := autoCorrectSampleLen
+		if c.maxPeek > 0 {
+			sample = min(sample, c.maxPeek)
+		}
+		n = max(n, sample)
+	}
+	return n
+}
+
+// transformer returns the transform.Transformer that converts input of the
+// given encoding to UTF-8 according to the configuration, including any
+// post-processing of the decoded output. bomLen is the length of the BOM that was
+// stripped from the input. It returns nil if the input needs neither conversion nor
+// post-processing.
+func (c *config) transformer(e Encoding, bomLen int) transform.Transformer {
+	// Every stream is mapped from its start, which is where the decoder is set up
+	if c.offsets != nil {
+		c.offsets.restart(bomLen)
+	}
+
+	var steps []transform.Transformer
+	d := c.decoder(e, bomLen)
+	if d != nil {
+		steps = append(steps, d)
+	}
+	if c.hasMaxReplacementRatio && d != nil {
+		steps = append(steps, &replacementLimiter{
+			replacement: c.replacementFor(e),
+			maxRatio:    c.maxReplacementRatio,
+		})
+	}
+	if c.hasReplacementString {
+		steps = append(steps, &replacementSubstituter{replacement: c.replacementString})
+	}
+	if c.normalizeNewlines {
+		normalizer := &newlineNormalizer{newline: '\n'}
+		if c.detectNewlines {
+			normalizer.detector = c.newlines
+		}
+		steps = append(steps, normalizer)
+	}
+	if c.keepBOM && bomLen > 0 {
+		steps = append(steps, &prefixer{prefix: utf8BOM})
+	}
+	if c.validateOutput && d != nil {
+		steps = append(steps, new(utf8Validator))
+	}
+
+	switch len(steps) {
+	case 0:
+		return nil
+	case 1:
+		return steps[0]
+	default:
+		return transform.Chain(steps...)
+	}
+}
+
+// decoder returns the transform.Transformer that converts input of the
+// given encoding to UTF-8 according to the configuration. It returns nil for
+// encodings that are UTF-8 already and need no conversion. The input is assumed
+// to start right after a BOM of bomLen bytes.
+func (c *config) decoder(e Encoding, bomLen int) transform.Transformer {
+	if factory, ok := c.decoders[e]; ok {
+		return factory()
+	}
+	if e == EncodingFallback && c.hint != nil {
+		return c.hint.NewDecoder()
+	}
+	if e == EncodingFallback {
+		return c.fallback.NewDecoder()
+	}
+
+	t := e.transformer()
+	if d, ok := t.(*utf16Decoder); ok {
+		d.strict = c.strict
+		d.surrogates = c.surrogates
+		d.start, d.offset = int64(bomLen), int64(bomLen)
+		d.rejectOdd = c.rejectOddLength
+		d.offsets = c.offsets
+		d.truncatedTail = c.truncatedTail
+		if bomLen > 0 && c.anomaly != nil {
+			d.swapCheck = func() {
+				*c.anomaly = true
+				c.logf("warning: the content looks byte-swapped against the BOM of %v", e)
+			}
+		}
+		if c.hasReplacement {
+			d.replacement = c.replacement
+		}
+	}
+	return t
+}
+
+// replacementFor returns the rune the decoder of e substitutes for malformed input.
+// Only the built-in UTF-16 decoders honor WithReplacement.
+func (c *config) replacementFor(e Encoding) rune {
+	if c.hasReplacement && c.decoders[e] == nil && (e == EncodingUTF16LE || e == EncodingUTF16BE) {
+		return c.replacement
+	}
+	return utf8.RuneError
+}
@@ -0,0 +1,92 @@
+package unutf16
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+// ErrInvalidRange is returned by NewRangeReader for a byte range that cannot be decoded,
+// such as one starting in the middle of a code unit.
+var ErrInvalidRange = errors.New("invalid byte range")
+
+// NewRangeReader initializes a new Reader that decodes length bytes of r starting at
+// offset start, such as a part of a large file. The encoding is detected from the start
+// of r, not the start of the range, honoring options such as WithSniff, WithCharsetHint
+// or WithDefaultEndianness. A range starting at offset 0 is decoded
+// like the whole input would be, including stripping the BOM. Any other range has to start
+// past the BOM and on a code unit boundary of the detected encoding, otherwise an error
+// wrapping ErrInvalidRange is returned. Offsets reported in a DecodeError are relative
+// to start.
+func NewRangeReader(r io.ReaderAt, start, length int64, opts ...Option) (*Reader, error) {
+	if start < 0 || length < 0 {
+		return nil, fmt.Errorf("range of %d bytes at offset %d: %w", length, start, ErrInvalidRange)
+	}
+
+	section := io.NewSectionReader(r, start, length)
+	if start == 0 {
+		return NewReader(section, opts...), nil
+	}
+
+	reader := NewReader(section, opts...)
+	// An invalid configuration is reported by the first Read, like for any other Reader
+	if reader.config.err != nil {
+		return reader, nil
+	}
+
+	prefix, err := peekAt(r, reader.config.peekLen())
+	if err != nil {
+		return nil, err
+	}
+	encoding, bomLen, err := reader.config.detect(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// The range has to start on a code unit, following the BOM
+	if start < int64(bomLen) || (start-int64(bomLen))%int64(encoding.unitLen()) != 0 {
+		return nil, fmt.Errorf("offset %d is not on a code unit boundary of %v: %w", start, encoding, ErrInvalidRange)
+	}
+
+	reader.config.forced = encoding
+	return reader, nil
+}
+
+// NewLengthPrefixedReader initializes a new Reader that decodes exactly byteLen bytes of r as
+// UTF-16 with the given byte order, such as a string field of a binary protocol that stores its
+// length ahead of it. Like NewReaderForced, it does not detect or strip a BOM. It never reads past
+// the byteLen bytes, so the caller can go on reading the fields that follow from r once the Reader
+// returned io.EOF. If r ends before byteLen bytes, the Reader returns io.ErrUnexpectedEOF.
+func NewLengthPrefixedReader(r io.Reader, byteLen int, e unicode.Endianness, opts ...Option) *Reader {
+	reader := NewReaderForced(&exactReader{source: r, remaining: int64(byteLen)}, e, opts...)
+	if byteLen < 0 {
+		reader.config.fail(&ConfigError{Reason: fmt.Sprintf("length %d is negative", byteLen)})
+	}
+	return reader
+}
+
+// exactReader is an io.Reader that reads a fixed number of bytes from its source,
+// like io.LimitReader, but reports a source that ends early as io.ErrUnexpectedEOF.
+type exactReader struct {
+	source    io.Reader // Underlying reader
+	remaining int64     // Number of bytes still to be read
+}
+
+// Read implements the io.Reader interface.
+func (e *exactReader) Read(p []byte) (int, error) {
+	if e.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > e.remaining {
+		p = p[:e.remaining]
+	}
+
+	n, err := e.source.Read(p)
+	e.remaining -= int64(n)
+	if err == io.EOF && e.remaining > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
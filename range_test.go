@@ -0,0 +1,211 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestRangeReader tests decoding a byte range of the input with the encoding of its BOM
+func TestRangeReader(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+	// UTF-32BE data (BOM + "hi")
+	utf32beData := []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		start    int64
+		length   int64
+		expected string
+	}{
+		{name: "whole input", input: utf16leData, start: 0, length: 12, expected: "hello"},
+		{name: "from start", input: utf16leData, start: 0, length: 6, expected: "he"},
+		{name: "middle", input: utf16leData, start: 4, length: 4, expected: "el"},
+		{name: "past end", input: utf16leData, start: 8, length: 100, expected: "lo"},
+		{name: "utf32", input: utf32beData, start: 8, length: 4, expected: "i"},
+		{name: "passthrough", input: []byte("hello"), start: 1, length: 3, expected: "ell"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader, err := unutf16.NewRangeReader(bytes.NewReader(tt.input), tt.start, tt.length)
+			if err != nil {
+				t.Fatalf("Error creating range reader: %v", err)
+			}
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestRangeReaderInvalidRange tests that ranges that cannot be decoded are rejected
+func TestRangeReaderInvalidRange(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	tests := []struct {
+		name     string
+		start    int64
+		length   int64
+		expected string
+	}{
+		{name: "odd offset", start: 3, length: 4, expected: "offset 3 is not on a code unit boundary of UTF-16LE: invalid byte range"},
+		{name: "inside bom", start: 1, length: 4, expected: "offset 1 is not on a code unit boundary of UTF-16LE: invalid byte range"},
+		{name: "negative", start: -2, length: 4, expected: "range of 4 bytes at offset -2: invalid byte range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader, err := unutf16.NewRangeReader(bytes.NewReader(utf16leData), tt.start, tt.length)
+
+			assert.ErrorIs(t, err, unutf16.ErrInvalidRange)
+			assert.EqualError(t, err, tt.expected)
+			assert.Nil(t, utf8Reader)
+		})
+	}
+}
+
+// TestRangeReaderOptions tests that the encoding of input without a BOM is detected from the start of the input as the options say
+func TestRangeReaderOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+	}{
+		{
+			name:     "default endianness",
+			input:    utf16le("hello"),
+			opts:     []unutf16.Option{unutf16.WithDefaultEndianness(unicode.LittleEndian)},
+			expected: "ello",
+		},
+		{
+			name:     "sniff",
+			input:    utf16be("hello"),
+			opts:     []unutf16.Option{unutf16.WithSniff(10)},
+			expected: "ello",
+		},
+		{
+			name:     "charset hint",
+			input:    utf16be("hello"),
+			opts:     []unutf16.Option{unutf16.WithCharsetHint(unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM))},
+			expected: "ello",
+		},
+		// Windows-1252 "héllo", where a single byte is a code unit
+		{
+			name:     "fallback encoding",
+			input:    []byte{0x68, 0xE9, 0x6C, 0x6C, 0x6F},
+			opts:     []unutf16.Option{unutf16.WithFallbackEncoding(charmap.Windows1252)},
+			expected: "éllo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := int64(len(tt.input) / 5)
+			utf8Reader, err := unutf16.NewRangeReader(bytes.NewReader(tt.input), start, int64(len(tt.input)), tt.opts...)
+			if err != nil {
+				t.Fatalf("Error creating range reader: %v", err)
+			}
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestRangeReaderDetectionFailure tests that input rejected by detection is reported
+func TestRangeReaderDetectionFailure(t *testing.T) {
+	utf8Reader, err := unutf16.NewRangeReader(bytes.NewReader([]byte("hello")), 1, 4, unutf16.WithRequireBOM())
+
+	assert.ErrorIs(t, err, unutf16.ErrMissingBOM)
+	assert.Nil(t, utf8Reader)
+}
+
+// TestRangeReaderPeekFailure tests that failures reading the BOM are reported as BOMPeekError
+func TestRangeReaderPeekFailure(t *testing.T) {
+	utf8Reader, err := unutf16.NewRangeReader(new(errorReaderAt), 2, 4)
+
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Nil(t, utf8Reader)
+}
+
+// TestLengthPrefixedReader tests that exactly the given number of bytes is decoded, leaving the rest of the stream
+func TestLengthPrefixedReader(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      []byte
+		byteLen    int
+		endianness unicode.Endianness
+		expected   string
+	}{
+		// UTF-16LE "hi" followed by the next field
+		{name: "little endian", input: []byte{0x68, 0x00, 0x69, 0x00, 0x2A, 0x2A}, byteLen: 4, endianness: unicode.LittleEndian, expected: "hi"},
+		// UTF-16BE "hi" followed by the next field
+		{name: "big endian", input: []byte{0x00, 0x68, 0x00, 0x69, 0x2A, 0x2A}, byteLen: 4, endianness: unicode.BigEndian, expected: "hi"},
+		// A BOM is decoded as a literal U+FEFF
+		{name: "bom", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x2A, 0x2A}, byteLen: 4, endianness: unicode.LittleEndian, expected: "\uFEFFh"},
+		{name: "empty", input: []byte{0x2A, 0x2A}, byteLen: 0, endianness: unicode.LittleEndian, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read one byte at a time so any read past the length would be noticed
+			source := bytes.NewReader(tt.input)
+			utf8Reader := unutf16.NewLengthPrefixedReader(iotest.OneByteReader(source), tt.byteLen, tt.endianness)
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+
+			rest, err := io.ReadAll(source)
+			if err != nil {
+				t.Fatalf("Error reading the rest of the stream: %v", err)
+			}
+			assert.Equal(t, []byte{0x2A, 0x2A}, rest)
+		})
+	}
+}
+
+// TestLengthPrefixedReaderTruncated tests that a stream ending before the given length is reported
+func TestLengthPrefixedReaderTruncated(t *testing.T) {
+	utf8Reader := unutf16.NewLengthPrefixedReader(bytes.NewReader([]byte{0x68, 0x00, 0x69}), 6, unicode.LittleEndian)
+
+	_, err := io.ReadAll(utf8Reader)
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+// TestLengthPrefixedReaderNegative tests that a negative length is rejected
+func TestLengthPrefixedReaderNegative(t *testing.T) {
+	_, err := io.ReadAll(unutf16.NewLengthPrefixedReader(bytes.NewReader([]byte("hi")), -1, unicode.LittleEndian))
+
+	var configErr *unutf16.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a ConfigError, got %v", err)
+	}
+	assert.EqualError(t, err, "invalid configuration: length -1 is negative")
+}
@@ -0,0 +1,133 @@
+package unutf16
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// ReadByte implements the io.ByteReader interface.
+// It returns the next byte of the decoded UTF-8 stream.
+func (r *Reader) ReadByte() (byte, error) {
+	defer r.report()
+
+	c, err := r.next()
+	if err != nil {
+		r.finish(err)
+		return 0, err
+	}
+	r.account([]byte{c})
+	return c, nil
+}
+
+// ReadRunes decodes the next n runes of the stream and returns them, such as the first
+// characters of a large file for a preview, like n calls of ReadRune. It stops reading the
+// source as soon as it has the n runes, so it never buffers more of the source than a single
+// Read of the decoder does, and the rest of the stream can be read as usual afterwards.
+// Fewer than n runes are only returned along with an error, which is io.EOF if the stream
+// ended first.
+func (r *Reader) ReadRunes(n int) ([]rune, error) {
+	// The progress callback hears about all of the runes at once
+	defer r.report()
+
+	// Do not trust n for the allocation, since the stream may be much shorter
+	runes := make([]rune, 0, min(max(n, 0), 1024))
+	for len(runes) < n {
+		decoded, _, err := r.readRune()
+		if err != nil {
+			return runes, err
+		}
+		runes = append(runes, decoded)
+	}
+	return runes, nil
+}
+
+// ReadRune implements the io.RuneReader interface.
+// It returns the next rune of the decoded UTF-8 stream and its size in bytes,
+// reassembling runes whose bytes arrive across separate reads. Bytes that do
+// not form a valid UTF-8 sequence are returned one at a time as U+FFFD with a size of 1.
+func (r *Reader) ReadRune() (rune, int, error) {
+	decoded, size, err := r.readRune()
+	r.report()
+	return decoded, size, err
+}
+
+// readRune is ReadRune without telling the progress callback.
+func (r *Reader) readRune() (rune, int, error) {
+	var buf [utf8.UTFMax]byte
+	first, err := r.next()
+	if err != nil {
+		r.finish(err)
+		return 0, 0, err
+	}
+	buf[0] = first
+	n := 1
+
+	// Collect the continuation bytes; an error here just ends the sequence early,
+	// and is reported again by the next call
+	for first >= utf8.RuneSelf && n < len(buf) && !utf8.FullRune(buf[:n]) {
+		b, err := r.next()
+		if err != nil {
+			break
+		}
+		buf[n] = b
+		n++
+	}
+
+	// Give back whatever did not belong to the rune, ahead of anything given back before
+	decoded, size := utf8.DecodeRune(buf[:n])
+	if size < n {
+		r.pending = append(append([]byte(nil), buf[size:n]...), r.pending...)
+	}
+	r.account(buf[:size])
+	return decoded, size, nil
+}
+
+// next returns the next byte of output, from the bytes peeked or given back first and
+// then straight from the decoder, without counting it as produced yet. Like for a
+// source read through the decoder, too many reads in a row that return nothing make
+// it give up with io.ErrNoProgress.
+func (r *Reader) next() (byte, error) {
+	if err := r.config.contextErr(); err != nil {
+		return 0, err
+	}
+	if r.decoder == nil {
+		err := r.initialize()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(r.pending) > 0 {
+		c := r.pending[0]
+		r.pending = r.pending[1:]
+		return c, nil
+	}
+
+	var b [1]byte
+	for empty := 0; ; {
+		n, err := r.decoder.Read(b[:])
+		if n > 0 {
+			return b[0], nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		empty++
+		if empty > r.config.emptyReadsLimit() {
+			return 0, io.ErrNoProgress
+		}
+	}
+}
+
+// account counts the bytes b handed out as produced, observing them on the way.
+func (r *Reader) account(b []byte) {
+	r.observe(b, false)
+	r.produced += int64(len(b))
+}
+
+// finish observes the end of the output once next returned io.EOF.
+func (r *Reader) finish(err error) {
+	if err == io.EOF {
+		r.observe(nil, true)
+	}
+}
@@ -0,0 +1,190 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestReadRune tests reading multi-byte runes from a source handing out one byte at a time
+func TestReadRune(t *testing.T) {
+	// UTF-16BE data (BOM + "hé👋")
+	utf16beData := []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0xE9, 0xD8, 0x3D, 0xDC, 0x4B}
+
+	utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(utf16beData)))
+
+	var runes []rune
+	var sizes []int
+	for {
+		r, size, err := utf8Reader.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading rune: %v", err)
+		}
+		runes = append(runes, r)
+		sizes = append(sizes, size)
+	}
+
+	assert.Equal(t, []rune("hé👋"), runes)
+	assert.Equal(t, []int{1, 2, 4}, sizes)
+}
+
+// TestReadRuneInvalid tests that invalid UTF-8 in passthrough input is returned as U+FFFD
+func TestReadRuneInvalid(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("\xE9ab")))
+
+	r, size, err := utf8Reader.ReadRune()
+	if err != nil {
+		t.Fatalf("Error reading rune: %v", err)
+	}
+	assert.Equal(t, utf8.RuneError, r)
+	assert.Equal(t, 1, size)
+
+	// The bytes read ahead while looking for continuation bytes are not lost
+	rest, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "ab", string(rest))
+}
+
+// TestReadRunes tests that the first runes are returned and the rest of the stream is left for reading
+func TestReadRunes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		// UTF-16BE data (BOM + "hé👋 world")
+		{name: "utf16be", input: append([]byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0xE9, 0xD8, 0x3D, 0xDC, 0x4B}, utf16be(" world")...)},
+		{name: "passthrough", input: []byte("hé👋 world")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)))
+
+			runes, err := utf8Reader.ReadRunes(3)
+			if err != nil {
+				t.Fatalf("Error reading runes: %v", err)
+			}
+			assert.Equal(t, []rune("hé👋"), runes)
+
+			rest, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+			assert.Equal(t, " world", string(rest))
+		})
+	}
+}
+
+// TestReadRunesEOF tests that a stream with fewer runes returns them along with io.EOF
+func TestReadRunesEOF(t *testing.T) {
+	// UTF-16LE data (BOM + "hi")
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}))
+
+	runes, err := utf8Reader.ReadRunes(5)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []rune("hi"), runes)
+
+	runes, err = utf8Reader.ReadRunes(0)
+	assert.NoError(t, err)
+	assert.Empty(t, runes)
+}
+
+// TestReadRunesStopsEarly tests that only a small part of a large source is read for the first runes
+func TestReadRunesStopsEarly(t *testing.T) {
+	data := benchmarkUTF16LE()
+	source := &readCounter{reader: bytes.NewReader(data)}
+	utf8Reader := unutf16.NewReader(source)
+
+	runes, err := utf8Reader.ReadRunes(5)
+	if err != nil {
+		t.Fatalf("Error reading runes: %v", err)
+	}
+
+	assert.Equal(t, []rune("héllo"), runes)
+	assert.Less(t, source.n, 16*1024)
+}
+
+// TestReadByte tests reading the decoded stream byte by byte
+func TestReadByte(t *testing.T) {
+	// UTF-16LE data (BOM + "é")
+	utf16leData := []byte{0xFF, 0xFE, 0xE9, 0x00}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16leData))
+
+	var output []byte
+	for {
+		b, err := utf8Reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading byte: %v", err)
+		}
+		output = append(output, b)
+	}
+
+	assert.Equal(t, "é", string(output))
+}
+
+// TestReadByteStubbornSource tests that a source passed through that stops making progress is reported rather than waited for forever
+func TestReadByteStubbornSource(t *testing.T) {
+	source := io.MultiReader(bytes.NewReader([]byte("abcd")), &stubbornReader{})
+	utf8Reader := unutf16.NewReader(source, unutf16.WithMaxEmptyReads(3))
+
+	for _, expected := range []byte("abcd") {
+		b, err := utf8Reader.ReadByte()
+		if err != nil {
+			t.Fatalf("Error reading byte: %v", err)
+		}
+		assert.Equal(t, expected, b)
+	}
+
+	_, err := utf8Reader.ReadByte()
+	assert.ErrorIs(t, err, io.ErrNoProgress)
+	_, _, err = utf8Reader.ReadRune()
+	assert.ErrorIs(t, err, io.ErrNoProgress)
+}
+
+// TestReadRunesProgress tests that the progress callback hears about each call once, and never sees the totals go backwards
+func TestReadRunesProgress(t *testing.T) {
+	var produced []int64
+	progress := func(_, p int64) {
+		produced = append(produced, p)
+	}
+
+	// An invalid lead byte, so ReadRune reads ahead and gives bytes back
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("\xE2héllo")), unutf16.WithProgress(progress))
+	runes, err := utf8Reader.ReadRunes(6)
+	if err != nil {
+		t.Fatalf("Error reading runes: %v", err)
+	}
+	assert.Equal(t, []rune("\uFFFDhéllo"), runes)
+	assert.Equal(t, []int64{7}, produced)
+
+	_, _, err = utf8Reader.ReadRune()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []int64{7, 7}, produced)
+}
+
+// readCounter counts the bytes read from reader.
+type readCounter struct {
+	reader io.Reader
+	n      int
+}
+
+func (c *readCounter) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += n
+	return n, err
+}
@@ -0,0 +1,32 @@
+package unutf16
+
+import (
+	"bufio"
+	"io"
+)
+
+// NewScanner returns a bufio.Scanner that splits the decoded UTF-8 stream of r into lines.
+// The input is decoded like NewReader does, so the options are passed on to the Reader.
+// Lines are returned without their terminating "\n" or "\r\n", which covers the CRLF
+// line endings common in UTF-16 files produced on Windows. The decoder only ever emits
+// complete runes, so a surrogate pair is never split across the scanner's buffer.
+func NewScanner(r io.Reader, opts ...Option) *bufio.Scanner {
+	return bufio.NewScanner(NewReader(r, opts...))
+}
+
+// DecodeLines decodes r like NewReader does and calls fn with each line of the decoded
+// UTF-8 stream, without its terminating "\n" or "\r\n", as split by NewScanner. A bare
+// "\r" does not end a line. The slice passed to fn is only valid until fn returns, since
+// the next line may overwrite it. If fn returns an error, decoding stops and that error
+// is returned as is. Otherwise DecodeLines returns the first error of decoding or
+// splitting, such as bufio.ErrTooLong for a line longer than bufio.MaxScanTokenSize,
+// or nil once the input is exhausted.
+func DecodeLines(r io.Reader, fn func(line []byte) error, opts ...Option) error {
+	scanner := NewScanner(r, opts...)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
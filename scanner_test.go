@@ -0,0 +1,85 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestScanner tests line scanning of CRLF terminated UTF-16 text
+func TestScanner(t *testing.T) {
+	text := "first line\r\nsecond 👋\r\nthird\n"
+
+	var encoded bytes.Buffer
+	_, err := unutf16.NewWriter(&encoded, unutf16.WithWriterEndianness(unicode.LittleEndian)).Write([]byte(text))
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+
+	// Handing out one byte at a time splits surrogate pairs across reads
+	scanner := unutf16.NewScanner(iotest.OneByteReader(&encoded))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Error scanning lines: %v", err)
+	}
+
+	assert.Equal(t, []string{"first line", "second 👋", "third"}, lines)
+}
+
+// TestDecodeLines tests that each line of CRLF terminated UTF-16 text is passed to the callback
+func TestDecodeLines(t *testing.T) {
+	text := "first line\r\nsecond 👋\r\n\r\nlast"
+
+	var encoded bytes.Buffer
+	_, err := unutf16.NewWriter(&encoded, unutf16.WithWriterEndianness(unicode.BigEndian)).Write([]byte(text))
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+
+	var lines []string
+	err = unutf16.DecodeLines(&encoded, func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error decoding lines: %v", err)
+	}
+
+	assert.Equal(t, []string{"first line", "second 👋", "", "last"}, lines)
+}
+
+// TestDecodeLinesStop tests that an error of the callback stops decoding and is returned
+func TestDecodeLinesStop(t *testing.T) {
+	stop := errors.New("stop")
+
+	var lines []string
+	err := unutf16.DecodeLines(bytes.NewReader([]byte("a\nb\nc\n")), func(line []byte) error {
+		lines = append(lines, string(line))
+		if len(lines) == 2 {
+			return stop
+		}
+		return nil
+	})
+
+	assert.Equal(t, stop, err)
+	assert.Equal(t, []string{"a", "b"}, lines)
+}
+
+// TestDecodeLinesFailure tests that read failures are reported
+func TestDecodeLinesFailure(t *testing.T) {
+	err := unutf16.DecodeLines(new(errorReader), func(line []byte) error {
+		return nil
+	})
+
+	assert.ErrorIs(t, err, simulatedError)
+}
@@ -0,0 +1,90 @@
+package unutf16
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// Stats holds the running totals of a Reader, as returned by Reader.Stats.
+type Stats struct {
+	// Consumed is the number of bytes read from the source, including the BOM and
+	// the peeked bytes that have not been handed out yet. It is only counted with
+	// WithStats or WithProgress.
+	Consumed int64
+	// Produced is the number of bytes of UTF-8 output handed out.
+	Produced int64
+	// Replacements is the number of replacement characters in the output: U+FFFD, or
+	// the rune passed to WithReplacement. Replacement characters that were part of the
+	// input already are counted as well. It is only counted with WithStats.
+	Replacements int64
+	// Newlines is the number of line endings in the output, where "\r\n", a bare "\r"
+	// and "\n" each count as one. It is only counted with WithStats.
+	Newlines int64
+}
+
+// Stats returns the running totals of the Reader. They cover the output handed out so
+// far, so they are only complete once the Reader has returned io.EOF.
+func (r *Reader) Stats() Stats {
+	stats := Stats{
+		Consumed: r.consumed,
+		Produced: r.produced,
+	}
+	if r.stats != nil {
+		stats.Replacements = r.stats.replacements
+		stats.Newlines = r.stats.newlines
+	}
+	return stats
+}
+
+// statsCounter counts the replacement characters and line endings in a stream of UTF-8
+// output, which may be split anywhere, including in the middle of a rune or a "\r\n".
+type statsCounter struct {
+	replacement  []byte // Encoding of the replacement character
+	replacements int64  // Number of replacement characters counted so far
+	newlines     int64  // Number of line endings counted so far
+	afterCR      bool   // The last byte counted was a "\r", whose "\n" must not count again
+
+	tail [utf8.UTFMax - 1]byte // Last bytes counted, which may start a replacement character
+	held int                   // Number of bytes in tail
+}
+
+// newStatsCounter returns a statsCounter that counts occurrences of replacement.
+func newStatsCounter(replacement rune) *statsCounter {
+	return &statsCounter{
+		replacement: utf8.AppendRune(nil, replacement),
+	}
+}
+
+// count adds the replacement characters and line endings in the next output b.
+func (s *statsCounter) count(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+
+	// A replacement character may be split between the previous output and b
+	tail := s.tail[:s.held]
+	for k := 1; k < len(s.replacement); k++ {
+		if bytes.HasSuffix(tail, s.replacement[:k]) && bytes.HasPrefix(b, s.replacement[k:]) {
+			s.replacements++
+			break
+		}
+	}
+	s.replacements += int64(bytes.Count(b, s.replacement))
+
+	// Every "\r" ends a line, and so does every "\n" that does not complete a "\r\n"
+	s.newlines += int64(bytes.Count(b, []byte{'\r'}) + bytes.Count(b, []byte{'\n'}))
+	s.newlines -= int64(bytes.Count(b, []byte("\r\n")))
+	if s.afterCR && b[0] == '\n' {
+		s.newlines--
+	}
+	s.afterCR = b[len(b)-1] == '\r'
+
+	// Keep the bytes that may start the next replacement character
+	keep := len(s.replacement) - 1
+	if len(b) >= keep {
+		s.held = copy(s.tail[:], b[len(b)-keep:])
+		return
+	}
+	tail = append(tail, b...)
+	s.held = copy(s.tail[:], tail[max(0, len(tail)-keep):])
+}
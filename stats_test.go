@@ -0,0 +1,126 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestStats tests that the running totals cover the whole output
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected unutf16.Stats
+	}{
+		{
+			name:     "passthrough",
+			input:    []byte("a\r\nb\rc\n\nd"),
+			expected: unutf16.Stats{Consumed: 9, Produced: 9, Newlines: 4},
+		},
+		{
+			name:     "passthrough replacement",
+			input:    []byte("a�b�"),
+			expected: unutf16.Stats{Consumed: 8, Produced: 8, Replacements: 2},
+		},
+		// UTF-16LE data (BOM + "a" + lone surrogate + "\r\n" + lone surrogate)
+		{
+			name:     "utf16le",
+			input:    []byte{0xFF, 0xFE, 0x61, 0x00, 0x00, 0xD8, 0x0D, 0x00, 0x0A, 0x00, 0x00, 0xDC},
+			expected: unutf16.Stats{Consumed: 12, Produced: 9, Replacements: 2, Newlines: 1},
+		},
+		// UTF-16LE data (BOM + "a" + lone surrogate), replaced with "?"
+		{
+			name:     "custom replacement",
+			input:    []byte{0xFF, 0xFE, 0x61, 0x00, 0x00, 0xD8},
+			opts:     []unutf16.Option{unutf16.WithReplacement('?')},
+			expected: unutf16.Stats{Consumed: 6, Produced: 2, Replacements: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" read", func(t *testing.T) {
+			opts := append([]unutf16.Option{unutf16.WithStats()}, tt.opts...)
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), opts...)
+
+			// Read one byte at a time so runes and line endings end up split across reads
+			_, err := io.ReadAll(iotest.OneByteReader(utf8Reader))
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.Stats())
+		})
+
+		t.Run(tt.name+" write to", func(t *testing.T) {
+			opts := append([]unutf16.Option{unutf16.WithStats()}, tt.opts...)
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), opts...)
+
+			_, err := utf8Reader.WriteTo(io.Discard)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.Stats())
+		})
+	}
+}
+
+// TestStatsReadRune tests that bytes ReadRune gives back are not counted twice
+func TestStatsReadRune(t *testing.T) {
+	// An invalid lead byte followed by a "\r" and a U+FFFD, so ReadRune reads ahead into them
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("\xE2\r�")), unutf16.WithStats())
+
+	for {
+		_, _, err := utf8Reader.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+	}
+
+	assert.Equal(t, unutf16.Stats{Consumed: 5, Produced: 5, Replacements: 1, Newlines: 1}, utf8Reader.Stats())
+}
+
+// TestStatsWriteToPassthrough tests that the bytes copied straight from the source once the newline style is known are counted
+func TestStatsWriteToPassthrough(t *testing.T) {
+	for _, opts := range [][]unutf16.Option{{unutf16.WithNewlineDetection()}, {unutf16.WithNewlineDetection(), unutf16.WithStats()}} {
+		utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("a\nbcdefgh")), opts...)
+
+		// Reading the first line settles the newline style, so io.Copy takes the fast path
+		line := make([]byte, 2)
+		_, err := io.ReadFull(utf8Reader, line)
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+		assert.Equal(t, unutf16.NewlineLF, utf8Reader.DetectedNewlineStyle())
+
+		n, err := io.Copy(io.Discard, utf8Reader)
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+
+		assert.Equal(t, int64(7), n)
+		assert.Equal(t, int64(9), utf8Reader.Stats().Produced)
+	}
+}
+
+// TestStatsDisabled tests that only the bytes produced are counted without WithStats
+func TestStatsDisabled(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("a\n�")))
+
+	_, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, unutf16.Stats{Produced: 5}, utf8Reader.Stats())
+}
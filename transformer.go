@@ -0,0 +1,141 @@
+package unutf16
+
+import (
+	"bytes"
+
+	"golang.org/x/text/transform"
+)
+
+// maxTransformerPeekLen bounds the sample the transformer returned by NewTransformer
+// waits for, so that it fits into the buffers golang.org/x/text uses between transformers.
+const maxTransformerPeekLen = 1024
+
+// NewTransformer returns a transform.Transformer that performs the same BOM-aware
+// conversion to UTF-8 as Reader, for use with transform.NewReader, transform.NewWriter
+// or transform.Chain. It recognizes the same BOMs as Reader, those of UTF-8, UTF-16 and
+// UTF-32, and holds back the start of the input by returning transform.ErrShortSrc until
+// it has seen enough bytes to detect the encoding: the 4 bytes of the longest BOM, since
+// "\xFF\xFE" may start either a UTF-16LE or a UTF-32LE BOM. When sniffing, the sample
+// is capped at 1 KiB.
+// A configuration error is returned from the first Transform call.
+func NewTransformer(opts ...Option) transform.Transformer {
+	return &detectingTransformer{
+		config: newConfig(opts),
+	}
+}
+
+// detectingTransformer is a transform.Transformer that detects the encoding of its
+// input on the first chunk and delegates the conversion to a transformer for that encoding.
+type detectingTransformer struct {
+	config  config                // Settings applied through the options passed to NewTransformer
+	decoder transform.Transformer // Transformer for the detected encoding, nil until detection
+}
+
+// Reset implements the transform.Transformer interface.
+// It restarts detection so that the transformer can be reused for another stream.
+func (t *detectingTransformer) Reset() {
+	t.decoder = nil
+}
+
+// Transform implements the transform.Transformer interface.
+func (t *detectingTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if t.config.err != nil {
+		return 0, 0, t.config.err
+	}
+
+	if t.decoder == nil {
+		// Request more input until the detection window is filled or the input ends
+		if len(src) < min(t.config.peekLen(), maxTransformerPeekLen) && !atEOF {
+			return 0, 0, transform.ErrShortSrc
+		}
+
+		// Detect the encoding; the BOM itself is never part of the output
+		var encoding Encoding
+		encoding, nSrc, err = t.config.detect(src)
+		if err != nil {
+			return 0, 0, err
+		}
+		t.decoder = t.config.transformer(encoding, nSrc)
+		if t.decoder == nil {
+			// Input that is UTF-8 already is relayed as is
+			t.decoder = transform.Nop
+		}
+	}
+
+	nDst, n, err := t.decoder.Transform(dst, src[nSrc:], atEOF)
+	return nDst, nSrc + n, err
+}
+
+// prefixer is a transform.Transformer that emits a fixed prefix ahead of its
+// otherwise unchanged input.
+type prefixer struct {
+	prefix  []byte // Bytes to emit before the input
+	written int    // Number of prefix bytes emitted so far
+}
+
+// Reset implements the transform.Transformer interface.
+func (p *prefixer) Reset() {
+	p.written = 0
+}
+
+// Transform implements the transform.Transformer interface.
+func (p *prefixer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if p.written < len(p.prefix) {
+		nDst = copy(dst, p.prefix[p.written:])
+		p.written += nDst
+		if p.written < len(p.prefix) {
+			return nDst, 0, transform.ErrShortDst
+		}
+	}
+
+	nSrc = copy(dst[nDst:], src)
+	if nSrc < len(src) {
+		err = transform.ErrShortDst
+	}
+	return nDst + nSrc, nSrc, err
+}
+
+// bomStripper is a transform.Transformer that drops a BOM at the start of its
+// otherwise unchanged input. It holds back the start of the input by returning
+// transform.ErrShortSrc while it could still be the start of the BOM.
+type bomStripper struct {
+	bom     []byte // BOM to drop
+	checked bool   // The start of the input has been checked for the BOM
+}
+
+// Reset implements the transform.Transformer interface.
+func (s *bomStripper) Reset() {
+	s.checked = false
+}
+
+// Transform implements the transform.Transformer interface.
+func (s *bomStripper) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if !s.checked {
+		if len(src) < len(s.bom) && !atEOF && bytes.HasPrefix(s.bom, src) {
+			return 0, 0, transform.ErrShortSrc
+		}
+		s.checked = true
+		if bytes.HasPrefix(src, s.bom) {
+			nSrc = len(s.bom)
+		}
+	}
+
+	nDst = copy(dst, src[nSrc:])
+	if nSrc+nDst < len(src) {
+		err = transform.ErrShortDst
+	}
+	return nDst, nSrc + nDst, err
+}
+
+// failingTransformer is a transform.Transformer that fails every Transform call.
+type failingTransformer struct {
+	err error // Error returned by Transform
+}
+
+// Reset implements the transform.Transformer interface.
+func (f *failingTransformer) Reset() {}
+
+// Transform implements the transform.Transformer interface.
+func (f *failingTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	return 0, 0, f.err
+}
@@ -0,0 +1,142 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/transform"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestTransformer tests BOM-aware conversion through the transformer
+func TestTransformer(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}},
+		{name: "utf32be", input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69}},
+		{name: "passthrough", input: []byte("hi")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := transform.Bytes(unutf16.NewTransformer(), tt.input)
+			if err != nil {
+				t.Fatalf("Error transforming input: %v", err)
+			}
+
+			assert.Equal(t, "hi", string(output))
+		})
+	}
+}
+
+// TestTransformerSplitBOM tests that the transformer waits for the complete BOM when input arrives byte by byte
+func TestTransformerSplitBOM(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	reader := transform.NewReader(iotest.OneByteReader(bytes.NewReader(utf16leData)), unutf16.NewTransformer())
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Error reading from transform reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestTransformerSplitBOMAllEncodings tests that every BOM is detected when it arrives byte by byte
+// inside a chain, including the UTF-32LE BOM that starts like the UTF-16LE BOM
+func TestTransformerSplitBOMAllEncodings(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}},
+		{name: "utf32le", input: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69, 0x00, 0x00, 0x00}},
+		{name: "utf32be", input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := transform.Chain(unutf16.NewTransformer(), transform.Nop)
+			reader := transform.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), chain)
+			output, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Error reading from transform reader: %v", err)
+			}
+
+			assert.Equal(t, "hi", string(output))
+		})
+	}
+}
+
+// TestTransformerShortSrc tests that the transformer requests more input until it can commit to an encoding
+func TestTransformerShortSrc(t *testing.T) {
+	transformer := unutf16.NewTransformer()
+	dst := make([]byte, 16)
+
+	// Could still be the UTF-32LE BOM
+	nDst, nSrc, err := transformer.Transform(dst, []byte{0xFF, 0xFE, 0x68}, false)
+	assert.ErrorIs(t, err, transform.ErrShortSrc)
+	assert.Equal(t, 0, nDst)
+	assert.Equal(t, 0, nSrc)
+
+	nDst, nSrc, err = transformer.Transform(dst, []byte{0xFF, 0xFE, 0x68, 0x00}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "h", string(dst[:nDst]))
+	assert.Equal(t, 4, nSrc)
+}
+
+// TestTransformerChain tests that the transformer composes with other transformers
+func TestTransformerChain(t *testing.T) {
+	// UTF-16BE data (BOM + "hi")
+	utf16beData := []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}
+
+	chain := transform.Chain(unutf16.NewTransformer(), transform.Nop)
+	output, _, err := transform.Bytes(chain, utf16beData)
+	if err != nil {
+		t.Fatalf("Error transforming input: %v", err)
+	}
+
+	assert.Equal(t, "hi", string(output))
+}
+
+// TestTransformerReset tests that a reset transformer detects the encoding again
+func TestTransformerReset(t *testing.T) {
+	transformer := unutf16.NewTransformer()
+
+	output, _, err := transform.Bytes(transformer, []byte{0xFF, 0xFE, 0x68, 0x00})
+	if err != nil {
+		t.Fatalf("Error transforming input: %v", err)
+	}
+	assert.Equal(t, "h", string(output))
+
+	// transform.Bytes resets the transformer before use
+	output, _, err = transform.Bytes(transformer, []byte("hello"))
+	if err != nil {
+		t.Fatalf("Error transforming input: %v", err)
+	}
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestTransformerKeepBOM tests that the transformer honors WithKeepBOM
+func TestTransformerKeepBOM(t *testing.T) {
+	// UTF-16LE data (BOM + "hi")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}
+
+	output, _, err := transform.Bytes(unutf16.NewTransformer(unutf16.WithKeepBOM()), utf16leData)
+	if err != nil {
+		t.Fatalf("Error transforming input: %v", err)
+	}
+
+	assert.Equal(t, "\xEF\xBB\xBFhi", string(output))
+}
@@ -2,8 +2,12 @@ package unutf16
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"time"
 
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
@@ -12,14 +16,60 @@ import (
 // NewReader initializes a new Reader that wraps an existing io.Reader.
 // This function prepares the Reader for converting UTF-16 encoded data to UTF-8,
 // but does not start decoding until the first Read call is made.
+// Options may be passed to adjust how input without a BOM is handled.
 // Returns a new Reader that wraps the provided io.Reader and handles UTF-16 to UTF-8 conversion.
-func NewReader(r io.Reader) *Reader {
+func NewReader(r io.Reader, opts ...Option) *Reader {
 	return &Reader{
 		source:  r,
 		decoder: nil,
+		config:  newConfig(opts),
 	}
 }
 
+// NewReaderContext initializes a new Reader like NewReader, whose reads can be cancelled through ctx.
+// Every Read call returns ctx.Err() once ctx is done. If ctx is done while the first Read is
+// still waiting for the BOM, that Read returns a *BOMPeekError wrapping ctx.Err() right away,
+// and all further reads fail. A source that implements io.Closer is closed to release the
+// abandoned read, and one that only has a SetReadDeadline method gets a deadline in the past.
+// For any other source the goroutine waiting on the read is leaked until that read returns.
+func NewReaderContext(ctx context.Context, r io.Reader, opts ...Option) *Reader {
+	reader := NewReader(r, opts...)
+	reader.config.ctx = ctx
+	return reader
+}
+
+// NewReaderForced initializes a new Reader like NewReader, which skips BOM detection
+// and decodes all input as UTF-16 with the given byte order. This is meant for input
+// with a wrong BOM, or one that is data: any leading BOM is not stripped but decoded
+// as a literal U+FEFF (ZERO WIDTH NO-BREAK SPACE). Options that affect detection, such
+// as WithSniff, WithDefaultEndianness or WithKeepBOM, have no effect.
+func NewReaderForced(r io.Reader, e unicode.Endianness, opts ...Option) *Reader {
+	reader := NewReader(r, opts...)
+	reader.config.forced = utf16Encoding(e)
+	return reader
+}
+
+// NewReadCloser initializes a new Reader like NewReader, for a source that has to be closed.
+// Returns the Reader as an io.ReadCloser whose Close method closes rc.
+func NewReadCloser(rc io.ReadCloser, opts ...Option) io.ReadCloser {
+	return NewReader(rc, opts...)
+}
+
+// DefaultReaderOptions are the options DecodeWith applies to every Reader it creates,
+// for setting a policy such as WithRequireBOM in one place across a codebase. Since
+// DecodeWith reads it without synchronization, it should only be assigned during program
+// initialization, such as in an init function, before any goroutine calls DecodeWith.
+var DefaultReaderOptions []Option
+
+// DecodeWith initializes a new Reader like NewReader, applying DefaultReaderOptions before
+// opts. Since options are applied in order, opts overrule the defaults they conflict with.
+func DecodeWith(r io.Reader, opts ...Option) *Reader {
+	// Copy the defaults, so appending opts never writes into the backing array they share
+	combined := make([]Option, 0, len(DefaultReaderOptions)+len(opts))
+	combined = append(combined, DefaultReaderOptions...)
+	return NewReader(r, append(combined, opts...)...)
+}
+
 // Reader is a custom io.Reader that wraps an existing io.Reader (source)
 // and optionally converts UTF-16 encoded data into UTF-8.
 // The decoder field is an internal io.Reader that handles the UTF-16 to UTF-8 conversion.
@@ -27,11 +77,36 @@ func NewReader(r io.Reader) *Reader {
 type Reader struct {
 	source  io.Reader // Underlying source reader (UTF-16 encoded)
 	decoder io.Reader // Decoder that will handle the conversion from UTF-16 to UTF-8
+	config  config    // Settings applied through the options passed to NewReader
+
+	encoding Encoding // Encoding detected during initialization
+	peeked   []byte   // Leading bytes inspected during initialization
+	bomLen   int      // Length of the BOM stripped during initialization
+	relayed  bool     // Input is relayed as is rather than converted
+	err      error    // Sticky error after a peek had to be abandoned
+	partial  []byte   // Bytes read by a peek that timed out, which the next peek starts with
+	pending  []byte   // Output served before the decoder: the peeked prefix or bytes ReadRune read ahead
+	consumed int64    // Bytes read from the source so far, counted only for the progress callback or Stats
+	produced int64    // Bytes of output handed out so far
+
+	newlines newlineDetector // Line ending of the first newline, recorded only with WithNewlineDetection
+	stats    *statsCounter   // Totals of Stats beyond the byte counts, counted only with WithStats
+	observed int64           // Bytes of output observed, so bytes given back by ReadRune count once
+	tail     bool            // The input ended in a high surrogate without its low surrogate
+	anomaly  bool            // The content following a UTF-16 BOM looks byte-swapped
 }
 
 // Read implements the io.Reader interface.
 // It lazily initializes the decoder on the first read, then streams the converted content.
+// A read deadline set on the source, such as on a net.Conn, is honored: a timeout while
+// peeking the BOM is returned as a *BOMPeekError, and the next Read resumes the peek with
+// the bytes read so far, while a timeout after that is returned as is.
 func (r *Reader) Read(p []byte) (int, error) {
+	// Give up right away once the context is done
+	if err := r.config.contextErr(); err != nil {
+		return 0, err
+	}
+
 	// Lazy initialization: perform BOM detection and setup the decoder on the first read call
 	if r.decoder == nil {
 		err := r.initialize()
@@ -40,46 +115,482 @@ func (r *Reader) Read(p []byte) (int, error) {
 		}
 	}
 
+	n, err := r.read(p)
+	r.observe(p[:n], err == io.EOF)
+	r.produced += int64(n)
+	r.report()
+	return n, err
+}
+
+// read serves the bytes peeked or given back by ReadRune, then delegates to the decoder.
+func (r *Reader) read(p []byte) (int, error) {
+	// Bytes peeked or given back by ReadRune come first
+	if len(r.pending) > 0 {
+		n := copy(p, r.pending)
+		r.pending = r.pending[n:]
+		return n, nil
+	}
+
 	// Now delegate the Read call to the decoder, which handles UTF-16 to UTF-8 conversion
 	return r.decoder.Read(p)
 }
 
+// observe inspects the output b, which starts at offset r.produced, for the first
+// newline with WithNewlineDetection and for the totals of Stats with WithStats. Bytes that ReadRune gave back
+// were inspected already and are skipped.
+func (r *Reader) observe(b []byte, atEOF bool) {
+	end := r.produced + int64(len(b))
+	if skip := r.observed - r.produced; skip > 0 {
+		b = b[min(skip, int64(len(b))):]
+	}
+	r.observed = max(r.observed, end)
+
+	if r.stats != nil {
+		r.stats.count(b)
+	}
+	// The normalizer records the line ending itself, as the output no longer shows it
+	if r.config.detectNewlines && !r.config.normalizeNewlines {
+		r.newlines.scan(b, atEOF)
+	}
+}
+
+// report passes the running totals to the progress callback, if there is one.
+func (r *Reader) report() {
+	if r.config.progress != nil {
+		r.config.progress(r.consumed, r.produced)
+	}
+}
+
+// Reset discards the Reader's state and makes it read from src instead,
+// keeping the options it was created with. The next Read call performs BOM
+// detection against src as if the Reader had been freshly constructed,
+// which allows a single Reader to be reused, for example through a sync.Pool.
+func (r *Reader) Reset(src io.Reader) {
+	*r = Reader{
+		source: src,
+		config: r.config,
+	}
+}
+
+// ErrSeekUnsupported is returned by Seek for any seek but one to the start of a source
+// that implements io.Seeker, since decoded offsets do not map to source offsets.
+var ErrSeekUnsupported = errors.New("seek is not supported")
+
+// Seek implements the io.Seeker interface, but only supports Seek(0, io.SeekStart),
+// which seeks the source back to its start and restarts decoding, including BOM
+// detection, as if the Reader had been freshly constructed. Since UTF-16 and UTF-8
+// have no fixed size ratio, any other seek returns ErrSeekUnsupported, and so does
+// a source that does not implement io.Seeker.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := r.source.(io.Seeker)
+	if !ok || offset != 0 || whence != io.SeekStart {
+		return 0, ErrSeekUnsupported
+	}
+
+	// A peek that had to be abandoned may still be reading the source
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	_, err := seeker.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	r.Reset(r.source)
+	return 0, nil
+}
+
+// Close implements the io.Closer interface.
+// It closes the underlying source if it implements io.Closer, and returns nil otherwise.
+func (r *Reader) Close() error {
+	if closer, ok := r.source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// copyBufferSize is the default size of the buffer WriteTo uses to move decoded data.
+const copyBufferSize = 32 * 1024
+
+// WriteTo implements the io.WriterTo interface, which io.Copy prefers over Read.
+// It lazily initializes the decoder like Read does, then streams the converted content
+// into w using a single buffer. Input that is passed through is handed to io.Copy
+// instead, so the source's own WriteTo can move it without any intermediate buffer,
+// and so can w's ReadFrom unless WithNewlineDetection is still looking for the first
+// newline. The returned
+// count is the number of UTF-8 bytes written.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	if r.decoder == nil {
+		err := r.initialize()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Bytes peeked or given back by ReadRune come first
+	var written int64
+	if len(r.pending) > 0 {
+		n, err := w.Write(r.pending)
+		r.observe(r.pending[:n], false)
+		written += int64(n)
+		r.pending = r.pending[n:]
+		r.produced += int64(n)
+		r.report()
+		if err != nil {
+			return written, err
+		}
+	}
+
+	// Nothing to convert, count or cancel, so let the source and w sort it out
+	if r.decoder == r.source && r.config.ctx == nil {
+		if !r.config.detectNewlines || r.newlines.found {
+			n, err := io.Copy(w, r.source)
+			r.produced += n
+			return written + n, err
+		}
+
+		// The first newline is still to be found, so w gets to see the bytes ahead of it
+		n, err := io.Copy(&outputObserver{w: w, r: r}, r.source)
+		if err == nil {
+			r.observe(nil, true)
+		}
+		return written + n, err
+	}
+
+	buf := make([]byte, r.config.bufferLen())
+	for {
+		if err := r.config.contextErr(); err != nil {
+			return written, err
+		}
+
+		n, err := r.decoder.Read(buf)
+		if n > 0 {
+			m, writeErr := w.Write(buf[:n])
+			r.observe(buf[:m], false)
+			written += int64(m)
+			r.produced += int64(m)
+			r.report()
+			if writeErr != nil {
+				return written, writeErr
+			}
+			if m != n {
+				return written, io.ErrShortWrite
+			}
+		}
+		if err == io.EOF {
+			r.observe(nil, true)
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// Bytes reads the rest of the decoded stream and returns it, like io.ReadAll on the Reader
+// does, but moves the data through WriteTo. It detects the encoding if no Read has done so
+// yet. Note that only the output that has not been read yet is returned: after earlier
+// Read or ReadRune calls, the bytes they returned are not part of the result. If an error
+// occurs, Bytes returns the output decoded up to that point along with the error; reaching
+// the end of the input is not an error.
+func (r *Reader) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	return buf.Bytes(), err
+}
+
+// outputObserver is an io.Writer that passes the output of a passthrough Reader's WriteTo
+// on to w, letting the Reader observe it on the way.
+type outputObserver struct {
+	w io.Writer
+	r *Reader
+}
+
+// Write implements the io.Writer interface.
+func (o *outputObserver) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.r.observe(p[:n], false)
+	o.r.produced += int64(n)
+	return n, err
+}
+
+// ErrEmptyInput is returned by a Reader created with WithRequireNonEmpty when its source
+// yields no bytes at all.
+var ErrEmptyInput = errors.New("input is empty")
+
 // initialize sets up the decoder by detecting the BOM and initializing the appropriate transform.Reader.
+// The encoding is never decided on part of a BOM: the peek either fills the whole detection window,
+// which covers the 4 bytes of a UTF-32 BOM, or reaches the end of a shorter stream, whose bytes are
+// then detected as they are, so a stream that ends in the middle of a BOM is treated as data. With
+// WithFlushEager, the peek only stops early once the bytes read cannot be the start of a longer BOM.
 func (r *Reader) initialize() error {
-	bom := make([]byte, 2)
-	// Read the first 2 bytes to check for BOM
-	_, err := r.source.Read(bom)
-	if err != nil && err != io.EOF {
-		return &BOMPeekError{
-			Cause: err,
+	// Options that cannot be honored make every read fail
+	if r.config.err != nil {
+		return r.config.err
+	}
+
+	// A peek that had to be abandoned leaves the source in an unknown state
+	if r.err != nil {
+		return r.err
+	}
+
+	// Count what is read from the source only if somebody is interested
+	input := r.source
+	var counter *countingReader
+	if r.config.progress != nil || r.config.stats {
+		counter = &countingReader{source: r.source, count: &r.consumed}
+		input = counter
+	}
+
+	// A source that keeps returning nothing must not make the peek or the decoder spin
+	guarded := &emptyReadGuard{source: input, limit: r.config.emptyReadsLimit()}
+
+	// Read the BOM window, or a larger sample when sniffing, to check for a BOM,
+	// picking up where a peek that timed out left off
+	prefix, err := r.peek(stitch(r.partial, guarded), counter)
+	if err != nil {
+		var peekErr *BOMPeekError
+		if errors.As(err, &peekErr) && isTimeout(peekErr.Cause) {
+			r.partial = peekErr.Partial
 		}
+		return err
+	}
+	r.partial = nil
+	if len(prefix) == 0 && r.config.requireNonEmpty {
+		return ErrEmptyInput
 	}
 
-	// Stitch everything back again
-	newReader := io.MultiReader(bytes.NewReader(bom), r.source)
+	// Detect the encoding; the BOM itself is never part of the output
+	r.config.truncatedTail, r.config.anomaly, r.config.newlines = &r.tail, &r.anomaly, &r.newlines
+	encoding, bomLen, err := r.config.detect(prefix)
+	if err != nil {
+		return err
+	}
+	if r.config.stats {
+		r.stats = newStatsCounter(r.config.replacementFor(encoding))
+	}
 
-	// Detect BOM and create the appropriate decoder
-	var decoder io.Reader
-	if len(bom) >= 2 && bom[0] == 0xFF && bom[1] == 0xFE {
-		// UTF-16 Little Endian
-		decoder = transform.NewReader(newReader, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder())
-	} else if len(bom) >= 2 && bom[0] == 0xFE && bom[1] == 0xFF {
-		// UTF-16 Big Endian
-		decoder = transform.NewReader(newReader, unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder())
+	// Create the appropriate decoder, stitching everything back again, including
+	// over-read bytes past the BOM or a short prefix if the stream ended early
+	if t := r.config.transformer(encoding, bomLen); t != nil {
+		r.decoder = transform.NewReader(stitch(prefix[bomLen:], guarded), t)
 	} else {
-		decoder = newReader
+		// Input that is UTF-8 already is relayed as is: once the remaining prefix
+		// has been served, reads go straight to the source without any wrapper
+		r.pending = prefix[bomLen:]
+		r.decoder = input
+		r.relayed = true
 	}
 
-	// Assign the decoder to the reader
-	r.decoder = decoder
+	r.encoding = encoding
+	r.peeked = prefix
+	r.bomLen = bomLen
 	return nil
 }
 
+// peek reads the detection window from input. With a context or a peek timeout it waits
+// for the read in a separate goroutine, so that it can give up as soon as either is done.
+// The bytes read are counted by counter, if there is one.
+func (r *Reader) peek(input io.Reader, counter *countingReader) ([]byte, error) {
+	size := r.config.peekLen()
+	var done func([]byte) bool
+	if r.config.flushEager && r.config.sniffLen == 0 && !r.config.autoCorrectEndianness {
+		done = r.config.decided
+	}
+	if r.config.ctx == nil && r.config.peekTimeout == 0 {
+		return peekUntil(input, size, done)
+	}
+
+	ctx := r.config.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if r.config.peekTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.peekTimeout)
+		defer cancel()
+	}
+
+	type result struct {
+		prefix []byte
+		err    error
+	}
+	// An abandoned read may still complete after a Reset, so the goroutine counts
+	// into a total of its own that is only added once the peek returned
+	var peeked int64
+	if counter != nil {
+		counter.count = &peeked
+	}
+
+	// Buffered, so the goroutine can finish even if nobody waits for it anymore
+	results := make(chan result, 1)
+	go func() {
+		prefix, err := peekUntil(input, size, done)
+		results <- result{prefix, err}
+	}()
+
+	select {
+	case res := <-results:
+		if counter != nil {
+			r.consumed += peeked
+			counter.count = &r.consumed
+		}
+		return res.prefix, res.err
+	case <-ctx.Done():
+		r.err = &BOMPeekError{
+			Cause: ctx.Err(),
+		}
+		// Closing the source, or failing its read through an expired deadline,
+		// releases the read blocked on it along with the goroutine
+		if closer, ok := r.source.(io.Closer); ok {
+			_ = closer.Close()
+		} else if deadliner, ok := r.source.(readDeadliner); ok {
+			_ = deadliner.SetReadDeadline(time.Unix(1, 0))
+		}
+		return nil, r.err
+	}
+}
+
+// readDeadliner is implemented by sources whose blocked reads can be failed through a
+// read deadline, such as net.Conn or *os.File.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// isTimeout reports whether err is a timeout the source may recover from, such as an
+// expired read deadline of a net.Conn or an *os.File.
+func isTimeout(err error) bool {
+	var timeout interface{ Timeout() bool }
+	return errors.Is(err, os.ErrDeadlineExceeded) || errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// countingReader is an io.Reader that counts the bytes read from its source.
+type countingReader struct {
+	source io.Reader // Underlying reader
+	count  *int64    // Running total of bytes read
+}
+
+// Read implements the io.Reader interface.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.source.Read(p)
+	*c.count += int64(n)
+	return n, err
+}
+
+// defaultMaxEmptyReads is the default number of consecutive empty reads of the source
+// tolerated, which matches what bufio tolerates.
+const defaultMaxEmptyReads = 100
+
+// emptyReadGuard is an io.Reader that fails with io.ErrNoProgress once its source
+// returned neither data nor an error for more than limit consecutive reads.
+type emptyReadGuard struct {
+	source io.Reader // Underlying reader
+	limit  int       // Number of consecutive empty reads tolerated
+	empty  int       // Number of consecutive empty reads so far
+}
+
+// Read implements the io.Reader interface.
+func (g *emptyReadGuard) Read(p []byte) (int, error) {
+	n, err := g.source.Read(p)
+	if n > 0 || err != nil || len(p) == 0 {
+		g.empty = 0
+		return n, err
+	}
+
+	g.empty++
+	if g.empty > g.limit {
+		return 0, io.ErrNoProgress
+	}
+	return 0, nil
+}
+
+// DetectedEncoding returns the encoding the Reader determined for its input.
+// It returns EncodingUnknown until the first Read call has inspected the input.
+func (r *Reader) DetectedEncoding() Encoding {
+	return r.encoding
+}
+
+// IsPassthrough reports whether the Reader relays its input as is, apart from stripping
+// a UTF-8 BOM, rather than converting it. This is the case for UTF-8 input unless an option
+// such as WithNormalizeNewlines or WithFallbackEncoding transforms it. It returns false until
+// the first Read call has inspected the input, and a stable answer from then on. Note that the
+// Reader holds on to the bytes it inspected, so its source cannot be read directly in its place;
+// io.Copy from a passthrough Reader already copies the rest of the input straight from its source.
+func (r *Reader) IsPassthrough() bool {
+	return r.relayed
+}
+
+// DetectedBOM returns a copy of the BOM the Reader recognized at the start of its input,
+// such as {0xFF, 0xFE} for UTF-16LE, so that it can be reproduced. It returns nil for
+// input without a BOM, and until the first Read call has inspected the input; use
+// DetectedEncoding to tell these apart.
+func (r *Reader) DetectedBOM() []byte {
+	if r.bomLen == 0 {
+		return nil
+	}
+	return bytes.Clone(r.peeked[:r.bomLen])
+}
+
+// DetectedNewlineStyle returns the line ending of the first newline in the decoded output
+// read so far: NewlineLF, NewlineCRLF or NewlineCR. It returns NewlineUnknown until a
+// newline has been read, and for a "\r" ending the output read so far until the byte
+// after it, or the end of the input, shows whether it starts a "\r\n". Only the first
+// newline is reported, so input that mixes line endings is not recognized as such. The
+// newline is only looked for with WithNewlineDetection; without it, NewlineUnknown is
+// always returned.
+func (r *Reader) DetectedNewlineStyle() NewlineStyle {
+	return r.newlines.detected()
+}
+
+// TruncatedTail reports whether the input ended in a UTF-16 high surrogate without the low
+// surrogate it requires, possibly followed by a single byte of it, such as for a file that was
+// cut off in the middle of a character. The surrogate is decoded according to the
+// SurrogatePolicy like any other unpaired surrogate. This is only meaningful once Read has
+// returned io.EOF, and TruncatedTail reports false for input that is not UTF-16.
+func (r *Reader) TruncatedTail() bool {
+	return r.tail
+}
+
+// DetectedAnomaly reports whether the content following a UTF-16 BOM looks byte-swapped, as
+// in a UTF-16LE file whose bytes were swapped, leaving a UTF-16BE BOM in front of content that
+// is actually little-endian. Most of the first 8 code units following the BOM have to be
+// printable ASCII with their bytes swapped, while they are not as they are. A warning is
+// reported to the logger of WithLogger, but the input is still decoded according to the BOM,
+// unless WithAutoCorrectEndianness overrules it, which counts as an anomaly as well. This is
+// only meaningful once those code units have been read, and shorter input is never flagged.
+func (r *Reader) DetectedAnomaly() bool {
+	return r.anomaly
+}
+
+// BOMLength returns the number of bytes the BOM of the input occupied: 2 for UTF-16,
+// 3 for UTF-8 and 4 for UTF-32. Adding it to a position in the decoded output helps to
+// map it back to the source. It returns 0 for input without a BOM, for a Reader created
+// with NewReaderForced, and until the first Read call has inspected the input.
+func (r *Reader) BOMLength() int {
+	return r.bomLen
+}
+
+// Peeked returns a copy of the leading bytes the Reader inspected to detect the encoding
+// of its input, including any BOM, which helps to diagnose input that decodes oddly.
+// With WithSniff this is the whole sample. It returns nil until the first Read call has
+// inspected the input.
+func (r *Reader) Peeked() []byte {
+	return bytes.Clone(r.peeked)
+}
+
 // BOMPeekError is a custom error type that represents an error encountered
 // while attempting to peek the Byte Order Mark (BOM) from an input stream.
 // This error wraps the original error (`Cause`) that occurred during the peek operation.
+// The bytes read before the error occurred are kept in `Partial`, and their count in `N`,
+// which helps to tell whether a source failed right away or in the middle of the BOM.
+// `Truncated` is set if the source reported io.ErrUnexpectedEOF, i.e. the stream ended
+// prematurely, such as a partial download, rather than failing with an arbitrary I/O error.
+// A source that is merely exhausted is not an error, as short input is passed through.
 type BOMPeekError struct {
-	Cause error
+	Cause     error
+	Partial   []byte
+	N         int
+	Truncated bool
 }
 
 // Error implements the error interface for BOMPeekError.
@@ -101,3 +612,71 @@ func (e *BOMPeekError) Error() string {
 func (e *BOMPeekError) Unwrap() error {
 	return e.Cause
 }
+
+// UnsupportedBOMError is a custom error type that represents input starting with
+// the Byte Order Mark (BOM) of an encoding that cannot be decoded, such as GB18030.
+// It is only returned by Readers created with WithStrictBOM, and records the name
+// of the encoding (`Name`) along with the raw BOM bytes (`BOM`) that were seen.
+type UnsupportedBOMError struct {
+	Name string
+	BOM  []byte
+}
+
+// Error implements the error interface for UnsupportedBOMError.
+// Returns a formatted error message that includes the encoding and its BOM.
+//
+// Example error message:
+//
+//	"unsupported BOM 84319533 (GB18030)"
+func (e *UnsupportedBOMError) Error() string {
+	return fmt.Sprintf("unsupported BOM %X (%s)", e.BOM, e.Name)
+}
+
+// ConfigError is a custom error type that represents a combination of options
+// that cannot be honored. NewReader detects it right away, and it is returned
+// from every subsequent Read call.
+type ConfigError struct {
+	Reason string
+}
+
+// Error implements the error interface for ConfigError.
+// Returns a formatted error message that includes the reason the options were rejected.
+//
+// Example error message:
+//
+//	"invalid configuration: WithStrict and WithReplacement are mutually exclusive"
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", e.Reason)
+}
+
+// DecodeError is a custom error type that represents malformed input encountered
+// while decoding in strict mode. This error wraps the reason (`Cause`) the input
+// was rejected, e.g. ErrInvalidSequence. The position of the offending sequence
+// within the input, counting the BOM, is recorded in `Offset`, and the number of
+// UTF-8 bytes the decoder produced before it in `ProducedOffset`, which locates the
+// problem in the partial output. Post-processing of the output, such as by
+// WithNormalizeNewlines or WithKeepBOM, is not accounted for in `ProducedOffset`.
+type DecodeError struct {
+	Cause          error
+	Offset         int64
+	ProducedOffset int64
+}
+
+// Error implements the error interface for DecodeError.
+// Returns a formatted error message that includes the underlying cause of the error.
+//
+// Example error message:
+//
+//	"failed to decode input at offset 6: invalid UTF-16 sequence"
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode input at offset %d: %v", e.Offset, e.Cause)
+}
+
+// Unwrap allows the DecodeError to expose the underlying error that caused the failure.
+//
+// Example usage:
+//
+//	if errors.Is(err, unutf16.ErrInvalidSequence) { ... }  // Allows matching against the wrapped error.
+func (e *DecodeError) Unwrap() error {
+	return e.Cause
+}
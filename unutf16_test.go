@@ -2,11 +2,26 @@ package unutf16_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
+	gounicode "unicode"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
 
 	"github.com/nolotz/unutf16"
 )
@@ -53,27 +68,1371 @@ func TestUTF16BEToUTF8(t *testing.T) {
 	}
 }
 
+// TestUTF32ToUTF8 tests conversion of UTF-32 in both byte orders to UTF-8
+func TestUTF32ToUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected unutf16.Encoding
+	}{
+		{
+			name:     "utf32le",
+			input:    []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69, 0x00, 0x00, 0x00},
+			expected: unutf16.EncodingUTF32LE,
+		},
+		{
+			name:     "utf32be",
+			input:    []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69},
+			expected: unutf16.EncodingUTF32BE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input))
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, "hi", string(output))
+			assert.Equal(t, tt.expected, utf8Reader.DetectedEncoding())
+		})
+	}
+}
+
 // TestNonUTF16Passthrough tests that non-UTF-16 data is passed through unmodified (e.g., UTF-8).
 func TestNonUTF16Passthrough(t *testing.T) {
 	// UTF-8 data (no BOM)
 	utf8Data := []byte("hello world")
 
-	reader := bytes.NewReader(utf8Data)
-	utf8Reader := unutf16.NewReader(reader)
+	reader := bytes.NewReader(utf8Data)
+	utf8Reader := unutf16.NewReader(reader)
+
+	// Expected UTF-8 output (should match input exactly)
+	expected := "hello world"
+	var output bytes.Buffer
+	_, err := io.Copy(&output, utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	if output.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, output.String())
+	}
+}
+
+// TestPartialBOMRead tests that a source handing out one byte per Read still has its BOM detected.
+func TestPartialBOMRead(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	reader := iotest.OneByteReader(bytes.NewReader(utf16leData))
+	utf8Reader := unutf16.NewReader(reader)
+
+	var output bytes.Buffer
+	_, err := io.Copy(&output, utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", output.String())
+}
+
+// TestOneByteSource tests detecting and decoding input from a source handing out one byte per Read,
+// such as a TTY or serial line, through both Read and WriteTo.
+func TestOneByteSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected unutf16.Encoding
+	}{
+		// UTF-16LE data (BOM + "héllo")
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}, expected: unutf16.EncodingUTF16LE},
+		// UTF-16BE data (BOM + "héllo")
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0xE9, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F}, expected: unutf16.EncodingUTF16BE},
+		{name: "passthrough", input: []byte("héllo"), expected: unutf16.EncodingPassthrough},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" read", func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)))
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, "héllo", string(output))
+			assert.Equal(t, tt.expected, utf8Reader.DetectedEncoding())
+		})
+
+		t.Run(tt.name+" write to", func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)))
+
+			var output bytes.Buffer
+			_, err := utf8Reader.WriteTo(&output)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, "héllo", output.String())
+			assert.Equal(t, tt.expected, utf8Reader.DetectedEncoding())
+		})
+	}
+}
+
+// TestBOMWindow tests that streams up to the length of the longest BOM are detected on the complete
+// BOM or treated as data, however the source hands out its bytes.
+func TestBOMWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+		encoding unutf16.Encoding
+	}{
+		{name: "empty", input: []byte{}, expected: "", encoding: unutf16.EncodingPassthrough},
+
+		{name: "1 byte ascii", input: []byte("a"), expected: "a", encoding: unutf16.EncodingPassthrough},
+		{name: "1 byte utf16le bom", input: []byte{0xFF}, expected: "\xFF", encoding: unutf16.EncodingPassthrough},
+		{name: "1 byte utf16be bom", input: []byte{0xFE}, expected: "\xFE", encoding: unutf16.EncodingPassthrough},
+		{name: "1 byte utf8 bom", input: []byte{0xEF}, expected: "\xEF", encoding: unutf16.EncodingPassthrough},
+		{name: "1 byte utf32be bom", input: []byte{0x00}, expected: "\x00", encoding: unutf16.EncodingPassthrough},
+
+		{name: "2 bytes ascii", input: []byte("ab"), expected: "ab", encoding: unutf16.EncodingPassthrough},
+		{name: "2 bytes utf16le bom", input: []byte{0xFF, 0xFE}, expected: "", encoding: unutf16.EncodingUTF16LE},
+		{name: "2 bytes utf16be bom", input: []byte{0xFE, 0xFF}, expected: "", encoding: unutf16.EncodingUTF16BE},
+		{name: "2 bytes utf8 bom", input: []byte{0xEF, 0xBB}, expected: "\xEF\xBB", encoding: unutf16.EncodingPassthrough},
+		{name: "2 bytes utf32be bom", input: []byte{0x00, 0x00}, expected: "\x00\x00", encoding: unutf16.EncodingPassthrough},
+
+		{name: "3 bytes ascii", input: []byte("abc"), expected: "abc", encoding: unutf16.EncodingPassthrough},
+		// The UTF-16 BOM followed by a dangling byte, which is not the start of a UTF-32 BOM
+		{name: "3 bytes utf16le bom", input: []byte{0xFF, 0xFE, 0x68}, expected: "\uFFFD", encoding: unutf16.EncodingUTF16LE},
+		{name: "3 bytes utf16le bom nul", input: []byte{0xFF, 0xFE, 0x00}, expected: "\uFFFD", encoding: unutf16.EncodingUTF16LE},
+		{name: "3 bytes utf16be bom", input: []byte{0xFE, 0xFF, 0x00}, expected: "\uFFFD", encoding: unutf16.EncodingUTF16BE},
+		{name: "3 bytes utf8 bom", input: []byte{0xEF, 0xBB, 0xBF}, expected: "", encoding: unutf16.EncodingUTF8BOM},
+		{name: "3 bytes utf32be bom", input: []byte{0x00, 0x00, 0xFE}, expected: "\x00\x00\xFE", encoding: unutf16.EncodingPassthrough},
+
+		{name: "4 bytes ascii", input: []byte("abcd"), expected: "abcd", encoding: unutf16.EncodingPassthrough},
+		{name: "4 bytes utf16le bom", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: "h", encoding: unutf16.EncodingUTF16LE},
+		{name: "4 bytes utf16be bom", input: []byte{0xFE, 0xFF, 0x00, 0x68}, expected: "h", encoding: unutf16.EncodingUTF16BE},
+		{name: "4 bytes utf8 bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68}, expected: "h", encoding: unutf16.EncodingUTF8BOM},
+		{name: "4 bytes utf32le bom", input: []byte{0xFF, 0xFE, 0x00, 0x00}, expected: "", encoding: unutf16.EncodingUTF32LE},
+		{name: "4 bytes utf32be bom", input: []byte{0x00, 0x00, 0xFE, 0xFF}, expected: "", encoding: unutf16.EncodingUTF32BE},
+	}
+
+	sources := []struct {
+		name   string
+		source func(b []byte) io.Reader
+	}{
+		{name: "whole", source: func(b []byte) io.Reader { return bytes.NewReader(b) }},
+		{name: "one byte", source: func(b []byte) io.Reader { return iotest.OneByteReader(bytes.NewReader(b)) }},
+		{name: "data with eof", source: func(b []byte) io.Reader { return iotest.DataErrReader(bytes.NewReader(b)) }},
+		{name: "half", source: func(b []byte) io.Reader { return iotest.HalfReader(bytes.NewReader(b)) }},
+	}
+
+	for _, tt := range tests {
+		for _, source := range sources {
+			t.Run(tt.name+" "+source.name, func(t *testing.T) {
+				utf8Reader := unutf16.NewReader(source.source(tt.input))
+
+				output, err := io.ReadAll(utf8Reader)
+				if err != nil {
+					t.Fatalf("Error reading from UTF8 reader: %v", err)
+				}
+
+				assert.Equal(t, tt.expected, string(output))
+				assert.Equal(t, tt.encoding, utf8Reader.DetectedEncoding())
+			})
+		}
+	}
+}
+
+// TestShortInputPassthrough tests that a stream shorter than the BOM is passed through unmodified.
+func TestShortInputPassthrough(t *testing.T) {
+	reader := bytes.NewReader([]byte("a"))
+	utf8Reader := unutf16.NewReader(reader)
+
+	var output bytes.Buffer
+	_, err := io.Copy(&output, utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "a", output.String())
+}
+
+// TestEmptyInput tests that an empty source yields an empty output without errors.
+func TestEmptyInput(t *testing.T) {
+	reader := bytes.NewReader(nil)
+	utf8Reader := unutf16.NewReader(reader)
+
+	var output bytes.Buffer
+	_, err := io.Copy(&output, utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Empty(t, output.Bytes())
+}
+
+// TestRequireNonEmpty tests that empty input is an error when input is required.
+func TestRequireNonEmpty(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader *unutf16.Reader
+	}{
+		{name: "detected", reader: unutf16.NewReader(bytes.NewReader(nil), unutf16.WithRequireNonEmpty())},
+		{name: "forced", reader: unutf16.NewReaderForced(bytes.NewReader(nil), unicode.LittleEndian, unutf16.WithRequireNonEmpty())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.reader.Read(make([]byte, 10))
+
+			assert.Equal(t, 0, n)
+			assert.ErrorIs(t, err, unutf16.ErrEmptyInput)
+			assert.NotErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+// TestRequireNonEmptyBOMOnly tests that a BOM on its own counts as input.
+func TestRequireNonEmptyBOMOnly(t *testing.T) {
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte{0xFF, 0xFE}), unutf16.WithRequireNonEmpty()))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Empty(t, output)
+}
+
+// TestDefaultEndianness tests that BOM-less input is decoded with the configured default endianness.
+func TestDefaultEndianness(t *testing.T) {
+	// UTF-16LE data without BOM ("hello")
+	utf16leData := []byte{0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	reader := bytes.NewReader(utf16leData)
+	utf8Reader := unutf16.NewReader(reader, unutf16.WithDefaultEndianness(unicode.LittleEndian))
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestDefaultEndiannessBOMWins tests that a BOM takes precedence over the configured default endianness.
+func TestDefaultEndiannessBOMWins(t *testing.T) {
+	// UTF-16BE data (BOM + "hello")
+	utf16beData := []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F}
+
+	reader := bytes.NewReader(utf16beData)
+	utf8Reader := unutf16.NewReader(reader, unutf16.WithDefaultEndianness(unicode.LittleEndian))
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestDecodeWith tests that DecodeWith applies the default options, which per-call options overrule.
+func TestDecodeWith(t *testing.T) {
+	defaults := unutf16.DefaultReaderOptions
+	t.Cleanup(func() { unutf16.DefaultReaderOptions = defaults })
+	unutf16.DefaultReaderOptions = []unutf16.Option{unutf16.WithReplacement('?')}
+
+	// UTF-16LE data (BOM + "h" + lone surrogate)
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x00, 0xD8}
+
+	output, err := io.ReadAll(unutf16.DecodeWith(bytes.NewReader(utf16leData)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "h?", string(output))
+
+	output, err = io.ReadAll(unutf16.DecodeWith(bytes.NewReader(utf16leData), unutf16.WithReplacement('!')))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "h!", string(output))
+
+	// Per-call options add to the defaults
+	_, err = io.ReadAll(unutf16.DecodeWith(bytes.NewReader([]byte("hi")), unutf16.WithRequireBOM()))
+	assert.ErrorIs(t, err, unutf16.ErrMissingBOM)
+	assert.Len(t, unutf16.DefaultReaderOptions, 1)
+}
+
+// TestTruncatedTail tests that input cut off after a high surrogate is reported once the Reader reached EOF.
+func TestTruncatedTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+		tail     bool
+	}{
+		// UTF-16LE data (BOM + "h" + high surrogate)
+		{name: "high surrogate", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x3D, 0xD8}, expected: "h\uFFFD", tail: true},
+		// UTF-16BE data (BOM + "h" + high surrogate + half of the low surrogate)
+		{name: "half low surrogate", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0xD8, 0x3D, 0xDE}, expected: "h\uFFFD\uFFFD", tail: true},
+		{name: "passthrough policy", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x3D, 0xD8}, opts: []unutf16.Option{unutf16.WithSurrogatePolicy(unutf16.SurrogatePassthrough)}, expected: "h\xED\xA0\xBD", tail: true},
+		// UTF-16LE data (BOM + "h" + high surrogate + "i")
+		{name: "interior surrogate", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x3D, 0xD8, 0x69, 0x00}, expected: "h\uFFFDi"},
+		// UTF-16LE data (BOM + "h" + lone low surrogate)
+		{name: "low surrogate", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x00, 0xDC}, expected: "h\uFFFD"},
+		// UTF-16LE data (BOM + U+1F600 as a surrogate pair)
+		{name: "complete pair", input: []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x00, 0xDE}, expected: "\U0001F600"},
+		{name: "utf8", input: []byte("h\xED\xA0"), expected: "h\xED\xA0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read one byte at a time so the surrogate arrives before the end of the input is known
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), tt.opts...)
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, tt.tail, utf8Reader.TruncatedTail())
+		})
+	}
+}
+
+// TestTruncatedTailReset tests that a reset Reader forgets the truncated tail of its previous source.
+func TestTruncatedTailReset(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte{0xFF, 0xFE, 0x3D, 0xD8}))
+	_, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.True(t, utf8Reader.TruncatedTail())
+
+	utf8Reader.Reset(bytes.NewReader([]byte{0xFF, 0xFE, 0x68, 0x00}))
+	assert.False(t, utf8Reader.TruncatedTail())
+	_, err = io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.False(t, utf8Reader.TruncatedTail())
+}
+
+// TestReaderForced tests that a forced endianness ignores the BOM and decodes it as data.
+func TestReaderForced(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		// UTF-16LE data without BOM ("hi")
+		{name: "no bom", input: []byte{0x68, 0x00, 0x69, 0x00}, expected: "hi"},
+		// UTF-16LE data (BOM + "hi")
+		{name: "matching bom", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: "\uFEFFhi"},
+		// UTF-16LE data (wrong BOM + "hi")
+		{name: "wrong bom", input: []byte{0xFE, 0xFF, 0x68, 0x00, 0x69, 0x00}, expected: "\uFFFEhi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReaderForced(bytes.NewReader(tt.input), unicode.LittleEndian)
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, unutf16.EncodingUTF16LE, utf8Reader.DetectedEncoding())
+		})
+	}
+}
+
+// TestDetectedEncoding tests that the detected encoding is reported after the first read.
+func TestDetectedEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected unutf16.Encoding
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: unutf16.EncodingUTF16LE},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68}, expected: unutf16.EncodingUTF16BE},
+		{name: "passthrough", input: []byte("hello"), expected: unutf16.EncodingPassthrough},
+		{name: "empty", input: nil, expected: unutf16.EncodingPassthrough},
+		{
+			name:     "default endianness",
+			input:    []byte{0x68, 0x00},
+			opts:     []unutf16.Option{unutf16.WithDefaultEndianness(unicode.LittleEndian)},
+			expected: unutf16.EncodingUTF16LE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), tt.opts...)
+			assert.Equal(t, unutf16.EncodingUnknown, utf8Reader.DetectedEncoding())
+
+			_, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.DetectedEncoding())
+		})
+	}
+}
+
+// TestIsPassthrough tests that the Reader reports whether it converts its input after the first read.
+func TestIsPassthrough(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected bool
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: false},
+		{name: "utf8", input: []byte("hello"), expected: true},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68}, expected: true},
+		{name: "normalized newlines", input: []byte("a\r\nb"), opts: []unutf16.Option{unutf16.WithNormalizeNewlines()}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), tt.opts...)
+			assert.False(t, utf8Reader.IsPassthrough())
+
+			_, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.IsPassthrough())
+		})
+	}
+}
+
+// TestDetectedBOM tests that the recognized BOM is reported after the first read.
+func TestDetectedBOM(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: []byte{0xFF, 0xFE}},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68}, expected: []byte{0xFE, 0xFF}},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68}, expected: []byte{0xEF, 0xBB, 0xBF}},
+		{name: "utf32be", input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68}, expected: []byte{0x00, 0x00, 0xFE, 0xFF}},
+		{name: "passthrough", input: []byte("hello"), expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input))
+			assert.Nil(t, utf8Reader.DetectedBOM())
+
+			_, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.DetectedBOM())
+		})
+	}
+}
+
+// TestBOMLength tests that the length of the stripped BOM is reported after the first read.
+func TestBOMLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected int
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: 2},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68}, expected: 2},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68}, expected: 3},
+		{name: "utf32le", input: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00}, expected: 4},
+		{name: "passthrough", input: []byte("hello"), expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input))
+			assert.Equal(t, 0, utf8Reader.BOMLength())
+
+			_, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, utf8Reader.BOMLength())
+		})
+	}
+}
+
+// TestPeeked tests that the inspected leading bytes are reported after the first read.
+func TestPeeked(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16leData))
+	assert.Nil(t, utf8Reader.Peeked())
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	peeked := utf8Reader.Peeked()
+	assert.Equal(t, "hello", string(output))
+	assert.Equal(t, []byte{0xFF, 0xFE, 0x68, 0x00}, peeked)
+
+	// The returned bytes are a copy
+	peeked[0] = 0x00
+	assert.Equal(t, []byte{0xFF, 0xFE, 0x68, 0x00}, utf8Reader.Peeked())
+}
+
+// TestInteriorBOM tests that only a BOM at the start of the input is stripped, while
+// later ones, such as those of concatenated files, are decoded as U+FEFF.
+func TestInteriorBOM(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		// UTF-16LE data (BOM + "a") twice
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x61, 0x00, 0xFF, 0xFE, 0x62, 0x00}, expected: "a\uFEFFb"},
+		// UTF-16BE data (BOM + "a") twice
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x61, 0xFE, 0xFF, 0x00, 0x62}, expected: "a\uFEFFb"},
+		// UTF-16LE data (BOM + "a" + BOM), split so the second BOM falls into the peeked prefix
+		{name: "utf16le within peek", input: []byte{0xFF, 0xFE, 0xFF, 0xFE, 0x61, 0x00}, expected: "\uFEFFa"},
+		// UTF-8 data (BOM + "a") twice
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x61, 0xEF, 0xBB, 0xBF, 0x62}, expected: "a\uFEFFb"},
+		{name: "passthrough", input: []byte("a\uFEFFb"), expected: "a\uFEFFb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestUTF8BOMStripped tests that a leading UTF-8 BOM is removed from the output.
+func TestUTF8BOMStripped(t *testing.T) {
+	// UTF-8 data (BOM + "hello")
+	utf8Data := []byte{0xEF, 0xBB, 0xBF, 0x68, 0x65, 0x6C, 0x6C, 0x6F}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf8Data))
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+	assert.Equal(t, unutf16.EncodingUTF8BOM, utf8Reader.DetectedEncoding())
+}
+
+// TestPartialUTF8BOMPassthrough tests that bytes resembling an incomplete UTF-8 BOM are passed through.
+func TestPartialUTF8BOMPassthrough(t *testing.T) {
+	utf8Data := []byte{0xEF, 0xBB}
+
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf8Data)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, utf8Data, output)
+}
+
+// TestWriteTo tests that WriteTo reports the number of UTF-8 bytes written.
+func TestWriteTo(t *testing.T) {
+	// UTF-16LE data (BOM + "héllo")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16leData))
+
+	var output bytes.Buffer
+	n, err := utf8Reader.WriteTo(&output)
+	if err != nil {
+		t.Fatalf("Error writing from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "héllo", output.String())
+	assert.Equal(t, int64(len("héllo")), n)
+}
+
+// TestWriteToPassthrough tests that passthrough input is copied by the source's own WriteTo.
+func TestWriteToPassthrough(t *testing.T) {
+	source := &writerToRecorder{Reader: bytes.NewReader([]byte("hello world"))}
+	utf8Reader := unutf16.NewReader(source)
+
+	// Serve part of the peeked prefix through Read first
+	buffer := make([]byte, 2)
+	_, err := utf8Reader.Read(buffer)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	var output bytes.Buffer
+	n, err := utf8Reader.WriteTo(&output)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "llo world", output.String())
+	assert.Equal(t, int64(len("llo world")), n)
+	assert.True(t, source.used)
+}
+
+// TestWriteToPassthroughReadFrom tests that the ReadFrom method of the destination gets to move input that is passed through.
+func TestWriteToPassthroughReadFrom(t *testing.T) {
+	// Hide the WriteTo method of the source so io.Copy uses ReadFrom
+	utf8Reader := unutf16.NewReader(struct{ io.Reader }{bytes.NewReader([]byte("hello world"))})
+
+	output := new(readerFromRecorder)
+	n, err := utf8Reader.WriteTo(output)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello world", output.String())
+	assert.Equal(t, int64(len("hello world")), n)
+	assert.True(t, output.used)
+}
+
+// TestBytes tests that Bytes returns the whole decoded stream.
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		// UTF-16LE data (BOM + "hé")
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}, expected: "hé"},
+		{name: "passthrough", input: []byte("hello"), expected: "hello"},
+		{name: "empty", input: []byte{}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := unutf16.NewReader(bytes.NewReader(tt.input)).Bytes()
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestBytesAfterRead tests that Bytes only returns the output that has not been read yet.
+func TestBytesAfterRead(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16leData))
+
+	buffer := make([]byte, 2)
+	_, err := io.ReadFull(utf8Reader, buffer)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	output, err := utf8Reader.Bytes()
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "llo", string(output))
+}
+
+// TestBytesFailure tests that Bytes returns the output decoded before an error along with the error.
+func TestBytesFailure(t *testing.T) {
+	// UTF-16LE data (BOM + "hi"), followed by a failing read
+	reader := io.MultiReader(bytes.NewReader([]byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}), new(errorReader))
+
+	output, err := unutf16.NewReader(reader).Bytes()
+
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Equal(t, "hi", string(output))
+}
+
+// TestReset tests that a reset Reader detects the BOM of its new source.
+func TestReset(t *testing.T) {
+	// UTF-16BE data (BOM + "hi")
+	utf16beData := []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")))
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "hello", string(output))
+	assert.Equal(t, unutf16.EncodingPassthrough, utf8Reader.DetectedEncoding())
+
+	utf8Reader.Reset(bytes.NewReader(utf16beData))
+	assert.Equal(t, unutf16.EncodingUnknown, utf8Reader.DetectedEncoding())
+
+	output, err = io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "hi", string(output))
+	assert.Equal(t, unutf16.EncodingUTF16BE, utf8Reader.DetectedEncoding())
+}
+
+// TestSeekStart tests that seeking to the start restarts decoding including BOM detection.
+func TestSeekStart(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16leData))
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "hello", string(output))
+
+	offset, err := utf8Reader.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Error seeking UTF8 reader: %v", err)
+	}
+	assert.Equal(t, int64(0), offset)
+	assert.Equal(t, unutf16.EncodingUnknown, utf8Reader.DetectedEncoding())
+
+	output, err = io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "hello", string(output))
+	assert.Equal(t, unutf16.EncodingUTF16LE, utf8Reader.DetectedEncoding())
+}
+
+// TestSeekUnsupported tests that seeks other than to the start of a seekable source are rejected.
+func TestSeekUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		source io.Reader
+		offset int64
+		whence int
+	}{
+		{name: "offset", source: bytes.NewReader([]byte("hello")), offset: 2, whence: io.SeekStart},
+		{name: "whence", source: bytes.NewReader([]byte("hello")), offset: 0, whence: io.SeekEnd},
+		{name: "not a seeker", source: iotest.OneByteReader(bytes.NewReader([]byte("hello"))), offset: 0, whence: io.SeekStart},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := unutf16.NewReader(tt.source).Seek(tt.offset, tt.whence)
+			assert.ErrorIs(t, err, unutf16.ErrSeekUnsupported)
+		})
+	}
+}
+
+// TestClose tests that closing the Reader closes a closable source.
+func TestClose(t *testing.T) {
+	source := &closeRecorder{Reader: bytes.NewReader([]byte("hello"))}
+
+	var utf8Reader io.ReadCloser = unutf16.NewReadCloser(source)
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.NoError(t, utf8Reader.Close())
+	assert.Equal(t, "hello", string(output))
+	assert.True(t, source.closed)
+}
+
+// TestCloseWithoutCloser tests that closing the Reader succeeds for sources without Close.
+func TestCloseWithoutCloser(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")))
+
+	assert.NoError(t, utf8Reader.Close())
+}
+
+// TestReaderContextCancelled tests that reads fail once the context is cancelled.
+func TestReaderContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	utf8Reader := unutf16.NewReaderContext(ctx, bytes.NewReader([]byte("hello world")))
+
+	buffer := make([]byte, 5)
+	n, err := utf8Reader.Read(buffer)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "hello"[:n], string(buffer[:n]))
+
+	cancel()
+	_, err = utf8Reader.Read(buffer)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestReaderContextBlockedPeek tests that a peek blocked on the source is abandoned once the context is done.
+func TestReaderContextBlockedPeek(t *testing.T) {
+	source, writer := io.Pipe()
+	defer writer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	utf8Reader := unutf16.NewReaderContext(ctx, source)
+
+	_, err := utf8Reader.Read(make([]byte, 10))
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The source has been closed to release the abandoned read
+	_, err = writer.Write([]byte{0xFF, 0xFE})
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+// TestPeekTimeout tests that a peek blocked on the source is abandoned once the timeout expires.
+func TestPeekTimeout(t *testing.T) {
+	source, writer := io.Pipe()
+	defer writer.Close()
+
+	utf8Reader := unutf16.NewReader(source, unutf16.WithPeekTimeout(10*time.Millisecond))
+
+	_, err := utf8Reader.Read(make([]byte, 10))
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The source is in an unknown state, so further reads fail as well
+	_, err = utf8Reader.Read(make([]byte, 10))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The source has been closed to release the abandoned read
+	_, err = writer.Write([]byte{0xFF, 0xFE})
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.NoError(t, utf8Reader.Close())
+}
+
+// TestPeekTimeoutReset tests that an abandoned read completing after Reset does not count towards the new source.
+func TestPeekTimeoutReset(t *testing.T) {
+	source := &gatedReader{data: []byte("ab"), gate: make(chan struct{}), drained: make(chan struct{})}
+	utf8Reader := unutf16.NewReader(source, unutf16.WithStats(), unutf16.WithPeekTimeout(10*time.Millisecond))
+
+	_, err := utf8Reader.Read(make([]byte, 10))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	utf8Reader.Reset(bytes.NewReader([]byte("hi")))
+	close(source.gate)
+	<-source.drained
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hi", string(output))
+	assert.Equal(t, int64(2), utf8Reader.Stats().Consumed)
+}
+
+// TestPeekTimeoutGoroutines tests that abandoned peeks of a source with a read deadline do not leak goroutines.
+func TestPeekTimeoutGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 8; i++ {
+		source := &deadlineReader{expired: make(chan struct{})}
+		utf8Reader := unutf16.NewReader(source, unutf16.WithPeekTimeout(time.Millisecond))
+
+		_, err := utf8Reader.Read(make([]byte, 10))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	// The goroutines return shortly after the deadline failed their reads
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+// TestPeekTimeoutInTime tests that a peek completing within the timeout decodes as usual.
+func TestPeekTimeoutInTime(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithPeekTimeout(time.Minute)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestPeekTimeoutConfigError tests that a peek timeout that is not positive is rejected.
+func TestPeekTimeoutConfigError(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")), unutf16.WithPeekTimeout(0))
+
+	_, err := utf8Reader.Read(make([]byte, 10))
+	assert.IsType(t, new(unutf16.ConfigError), err)
+	assert.EqualError(t, err, "invalid configuration: peek timeout 0s is not positive")
+}
+
+// TestFlushEager tests that the first Read does not wait for more input once the encoding is certain.
+func TestFlushEager(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   [][]byte
+		opts     []unutf16.Option
+		expected string
+	}{
+		// UTF-16LE data without BOM ("h")
+		{name: "no bom", chunks: [][]byte{{0x68, 0x00}}, opts: []unutf16.Option{unutf16.WithDefaultEndianness(unicode.LittleEndian)}, expected: "h"},
+		// UTF-16LE data (BOM + "h"), which could be the start of a UTF-32LE BOM until the "h"
+		{name: "utf16le bom", chunks: [][]byte{{0xFF, 0xFE}, {0x68, 0x00}}, expected: "h"},
+		{name: "passthrough", chunks: [][]byte{[]byte("h")}, expected: "h"},
+	}
 
-	// Expected UTF-8 output (should match input exactly)
-	expected := "hello world"
-	var output bytes.Buffer
-	_, err := io.Copy(&output, utf8Reader)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, writer := io.Pipe()
+			defer writer.Close()
+			go func() {
+				for _, chunk := range tt.chunks {
+					_, _ = writer.Write(chunk)
+				}
+			}()
+
+			utf8Reader := unutf16.NewReader(source, append(tt.opts, unutf16.WithFlushEager())...)
+			buffer := make([]byte, 10)
+			n, err := utf8Reader.Read(buffer)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(buffer[:n]))
+		})
+	}
+}
+
+// TestKeepBOM tests that a detected BOM is emitted as a UTF-8 BOM when requested.
+func TestKeepBOM(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected []byte
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00}, expected: []byte{0xEF, 0xBB, 0xBF, 0x68}},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68}, expected: []byte{0xEF, 0xBB, 0xBF, 0x68}},
+		{name: "passthrough", input: []byte{0x68}, expected: []byte{0x68}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(tt.input), unutf16.WithKeepBOM()))
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, output)
+		})
+	}
+}
+
+// TestDecoderFor tests that a custom decoder replaces the built-in one for its encoding.
+func TestDecoderFor(t *testing.T) {
+	var calls int
+	upper := func() transform.Transformer {
+		calls++
+		return transform.Chain(unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder(), runes.Map(gounicode.ToUpper))
+	}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+		calls    int
+	}{
+		// UTF-16LE data (BOM + "hi")
+		{name: "overridden", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, expected: "HI", calls: 1},
+		// UTF-16BE data (BOM + "hi")
+		{name: "built-in", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}, expected: "hi", calls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), unutf16.WithDecoderFor(unutf16.EncodingUTF16LE, upper))
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+// TestDecoderForPassthrough tests that input without a BOM can be decoded by a custom decoder.
+func TestDecoderForPassthrough(t *testing.T) {
+	// Windows-1252 data ("café")
+	windows1252Data := []byte{0x63, 0x61, 0x66, 0xE9}
+
+	decoder := func() transform.Transformer {
+		return charmap.Windows1252.NewDecoder()
+	}
+	utf8Reader := unutf16.NewReader(bytes.NewReader(windows1252Data), unutf16.WithDecoderFor(unutf16.EncodingPassthrough, decoder))
+
+	output, err := io.ReadAll(utf8Reader)
 	if err != nil {
 		t.Fatalf("Error reading from UTF8 reader: %v", err)
 	}
 
-	if output.String() != expected {
-		t.Errorf("Expected '%s', got '%s'", expected, output.String())
+	assert.Equal(t, "café", string(output))
+}
+
+// TestCharsetHint tests that the BOM priority decides between a BOM and a charset hint.
+func TestCharsetHint(t *testing.T) {
+	utf16le := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	utf16 := unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+		encoding unutf16.Encoding
+	}{
+		// UTF-16LE data without BOM ("hi")
+		{name: "no bom", input: []byte{0x68, 0x00, 0x69, 0x00}, opts: []unutf16.Option{unutf16.WithCharsetHint(utf16le)}, expected: "hi", encoding: unutf16.EncodingFallback},
+		// UTF-16BE data (BOM + "hi") labeled as UTF-16LE
+		{name: "bom wins", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}, opts: []unutf16.Option{unutf16.WithCharsetHint(utf16le)}, expected: "hi", encoding: unutf16.EncodingUTF16BE},
+		// UTF-16LE data ("\uFFFEhi") labeled as UTF-16LE
+		{name: "hint wins", input: []byte{0xFE, 0xFF, 0x68, 0x00, 0x69, 0x00}, opts: []unutf16.Option{unutf16.WithCharsetHint(utf16le), unutf16.WithBOMPriority(unutf16.HintWins)}, expected: "\uFFFEhi", encoding: unutf16.EncodingFallback},
+		// UTF-16LE data (BOM + "hi") labeled as UTF-16LE
+		{name: "hint agrees", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, opts: []unutf16.Option{unutf16.WithCharsetHint(utf16le), unutf16.WithBOMPriority(unutf16.HintWins)}, expected: "hi", encoding: unutf16.EncodingUTF16LE},
+		// UTF-16LE data (BOM + "hi") labeled as UTF-16 of either byte order
+		{name: "generic hint agrees", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}, opts: []unutf16.Option{unutf16.WithCharsetHint(utf16), unutf16.WithBOMPriority(unutf16.HintWins)}, expected: "hi", encoding: unutf16.EncodingUTF16LE},
+		// UTF-8 data (BOM + "café") labeled as Windows-1252
+		{name: "charmap hint wins", input: []byte{0xEF, 0xBB, 0xBF, 0x63, 0x61, 0x66, 0xE9}, opts: []unutf16.Option{unutf16.WithCharsetHint(charmap.Windows1252), unutf16.WithBOMPriority(unutf16.HintWins)}, expected: "ï»¿café", encoding: unutf16.EncodingFallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), tt.opts...)
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, tt.encoding, utf8Reader.DetectedEncoding())
+		})
+	}
+}
+
+// TestCharsetHintConfigError tests that conflicting charset hint configurations are rejected.
+func TestCharsetHintConfigError(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []unutf16.Option
+		expected string
+	}{
+		{
+			name:     "with fallback",
+			opts:     []unutf16.Option{unutf16.WithCharsetHint(charmap.Windows1252), unutf16.WithFallbackEncoding(charmap.ISO8859_1)},
+			expected: "invalid configuration: WithCharsetHint and WithFallbackEncoding are mutually exclusive",
+		},
+		{
+			name:     "with default endianness",
+			opts:     []unutf16.Option{unutf16.WithCharsetHint(charmap.Windows1252), unutf16.WithDefaultEndianness(unicode.BigEndian)},
+			expected: "invalid configuration: WithCharsetHint and WithDefaultEndianness are mutually exclusive",
+		},
+		{
+			name:     "unknown priority",
+			opts:     []unutf16.Option{unutf16.WithBOMPriority(7)},
+			expected: "invalid configuration: BOM priority 7 is unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")), tt.opts...)
+
+			_, err := utf8Reader.Read(make([]byte, 10))
+			assert.IsType(t, new(unutf16.ConfigError), err)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestFallbackEncoding tests that input without a BOM is decoded with the fallback encoding.
+func TestFallbackEncoding(t *testing.T) {
+	// Windows-1252 data ("café €")
+	windows1252Data := []byte{0x63, 0x61, 0x66, 0xE9, 0x20, 0x80}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(windows1252Data), unutf16.WithFallbackEncoding(charmap.Windows1252))
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "café €", string(output))
+	assert.Equal(t, unutf16.EncodingFallback, utf8Reader.DetectedEncoding())
+}
+
+// TestFallbackEncodingBOMWins tests that a BOM takes precedence over the fallback encoding.
+func TestFallbackEncodingBOMWins(t *testing.T) {
+	// UTF-16LE data (BOM + "é")
+	utf16leData := []byte{0xFF, 0xFE, 0xE9, 0x00}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithFallbackEncoding(charmap.Windows1252))
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "é", string(output))
+	assert.Equal(t, unutf16.EncodingUTF16LE, utf8Reader.DetectedEncoding())
+}
+
+// TestBufferSize tests that data is copied correctly through buffers of any positive size.
+func TestBufferSize(t *testing.T) {
+	// UTF-16LE data (BOM + "héllo")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	for _, size := range []int{1, 3, 1024} {
+		var output bytes.Buffer
+		_, err := unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithBufferSize(size)).WriteTo(&output)
+		if err != nil {
+			t.Fatalf("Error writing from UTF8 reader: %v", err)
+		}
+
+		assert.Equal(t, "héllo", output.String())
+	}
+}
+
+// TestBufferSizeSmallReads tests that small reads are served from the read-ahead whatever its size.
+func TestBufferSizeSmallReads(t *testing.T) {
+	// UTF-16LE data (BOM + "h😀" + unpaired high surrogate + "i")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x3D, 0xD8, 0x00, 0xDE, 0x3D, 0xD8, 0x69, 0x00}
+
+	for _, size := range []int{1, 3, 64, 1024} {
+		utf8Reader := unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithBufferSize(size))
+
+		output, err := io.ReadAll(iotest.OneByteReader(utf8Reader))
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+
+		assert.Equal(t, "h😀\uFFFDi", string(output))
+	}
+}
+
+// TestBufferSizeSourceError tests that a failing source is reported once the output decoded before the failure has been read.
+func TestBufferSizeSourceError(t *testing.T) {
+	// UTF-16LE data (BOM + "hello")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+	utf8Reader := unutf16.NewReader(io.MultiReader(bytes.NewReader(utf16leData), new(errorReader)))
+
+	output, err := io.ReadAll(utf8Reader)
+
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestBufferSizeConfigError tests that non-positive buffer sizes are rejected.
+func TestBufferSizeConfigError(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		_, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte("hello")), unutf16.WithBufferSize(size)))
+
+		assert.IsType(t, new(unutf16.ConfigError), err)
+	}
+}
+
+// TestDataWithEOF tests sources that return their last bytes together with io.EOF.
+func TestDataWithEOF(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks [][]byte
+	}{
+		// BOM + "h", all at once
+		{name: "single read", chunks: [][]byte{{0xFF, 0xFE, 0x68, 0x00}}},
+		// BOM first, then "h" together with io.EOF
+		{name: "bom then data", chunks: [][]byte{{0xFF, 0xFE}, {0x68, 0x00}}},
+		// Half of the BOM, then the rest together with io.EOF
+		{name: "split bom", chunks: [][]byte{{0xFF}, {0xFE, 0x68, 0x00}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(&eofReader{chunks: tt.chunks})
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, "h", string(output))
+			assert.Equal(t, unutf16.EncodingUTF16LE, utf8Reader.DetectedEncoding())
+		})
+	}
+}
+
+// TestProgress tests that the progress callback receives the running totals.
+func TestProgress(t *testing.T) {
+	// UTF-16LE data (BOM + "héllo")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+
+	var consumed, produced []int64
+	progress := func(c, p int64) {
+		consumed = append(consumed, c)
+		produced = append(produced, p)
+	}
+
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithProgress(progress)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "héllo", string(output))
+	assert.NotEmpty(t, consumed)
+	assert.Equal(t, int64(len(utf16leData)), consumed[len(consumed)-1])
+	assert.Equal(t, int64(len("héllo")), produced[len(produced)-1])
+}
+
+// TestProgressReadRune tests that bytes given back by ReadRune are not counted twice.
+func TestProgressReadRune(t *testing.T) {
+	var produced int64
+	progress := func(_, p int64) {
+		produced = p
+	}
+
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("\xE9ab")), unutf16.WithProgress(progress))
+	_, _, err := utf8Reader.ReadRune()
+	if err != nil {
+		t.Fatalf("Error reading rune: %v", err)
+	}
+	_, err = io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, int64(3), produced)
+}
+
+// TestMaxEmptyReads tests that a source that never makes progress is reported as io.ErrNoProgress.
+func TestMaxEmptyReads(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		opts     []unutf16.Option
+		expected string
+	}{
+		{name: "during peek", data: nil},
+		// UTF-16LE data (BOM + "hello")
+		{name: "while decoding", data: []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}, expected: "hello"},
+		{name: "configured", data: nil, opts: []unutf16.Option{unutf16.WithMaxEmptyReads(3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(&stubbornReader{data: tt.data}, tt.opts...)
+
+			output, err := io.ReadAll(utf8Reader)
+			assert.ErrorIs(t, err, io.ErrNoProgress)
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestMaxEmptyReadsTolerated tests that a few empty reads of the source do not fail the Reader.
+func TestMaxEmptyReadsTolerated(t *testing.T) {
+	// UTF-16LE data (BOM + "hi"), with an empty read after every chunk
+	reader := &eofReader{chunks: [][]byte{{0xFF, 0xFE}, {}, {0x68, 0x00}, {}, {0x69, 0x00}}}
+
+	output, err := io.ReadAll(unutf16.NewReader(reader, unutf16.WithMaxEmptyReads(1)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hi", string(output))
+}
+
+// TestEmptyFirstRead tests that a source returning no data on its first Read, like a fresh pipe, still has its BOM detected.
+func TestEmptyFirstRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   [][]byte
+		expected string
+		encoding unutf16.Encoding
+	}{
+		// UTF-16LE data (BOM + "hi")
+		{name: "utf16le", chunks: [][]byte{{}, {0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}}, expected: "hi", encoding: unutf16.EncodingUTF16LE},
+		// UTF-32LE data (BOM + "h"), whose BOM only becomes complete after another empty read
+		{name: "utf32le", chunks: [][]byte{{}, {0xFF, 0xFE}, {}, {0x00, 0x00, 0x68, 0x00, 0x00, 0x00}}, expected: "h", encoding: unutf16.EncodingUTF32LE},
+		{name: "passthrough", chunks: [][]byte{{}, []byte("hi")}, expected: "hi", encoding: unutf16.EncodingPassthrough},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" read", func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(&eofReader{chunks: slices.Clone(tt.chunks)})
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+			assert.Equal(t, tt.encoding, utf8Reader.DetectedEncoding())
+		})
+
+		t.Run(tt.name+" write to", func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(&eofReader{chunks: slices.Clone(tt.chunks)})
+
+			var output bytes.Buffer
+			_, err := utf8Reader.WriteTo(&output)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, output.String())
+			assert.Equal(t, tt.encoding, utf8Reader.DetectedEncoding())
+		})
+
+		t.Run(tt.name+" detect", func(t *testing.T) {
+			encoding, _, err := unutf16.Detect(&eofReader{chunks: slices.Clone(tt.chunks)})
+			if err != nil {
+				t.Fatalf("Error detecting encoding: %v", err)
+			}
+
+			assert.Equal(t, tt.encoding, encoding)
+		})
 	}
 }
 
+// TestDetectNoProgress tests that Detect gives up on a source that never returns any data.
+func TestDetectNoProgress(t *testing.T) {
+	_, _, err := unutf16.Detect(new(stubbornReader))
+
+	assert.IsType(t, new(unutf16.BOMPeekError), err)
+	assert.ErrorIs(t, err, io.ErrNoProgress)
+}
+
+// TestMaxEmptyReadsConfigError tests that a maximum of empty reads that is not positive is rejected.
+func TestMaxEmptyReadsConfigError(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")), unutf16.WithMaxEmptyReads(0))
+
+	_, err := utf8Reader.Read(make([]byte, 10))
+	assert.IsType(t, new(unutf16.ConfigError), err)
+	assert.EqualError(t, err, "invalid configuration: maximum empty reads 0 is not positive")
+}
+
 // TestPeekFailure simulates a failure during the Peek operation by using ErrorReader.
 func TestPeekFailure(t *testing.T) {
 	// Create an ErrorReader that triggers an error after 0 bytes (to simulate a peek failure)
@@ -94,6 +1453,119 @@ func TestPeekFailure(t *testing.T) {
 	assert.Equal(t, "failed to peek BOM: simulated read error", err.Error())
 }
 
+// TestPeekFailurePartial tests that bytes read before a peek failure are reported.
+func TestPeekFailurePartial(t *testing.T) {
+	// The source hands out the first BOM byte, then fails
+	reader := io.MultiReader(bytes.NewReader([]byte{0xFF}), new(errorReader))
+
+	_, err := unutf16.NewReader(reader).Read(make([]byte, 10))
+
+	var peekErr *unutf16.BOMPeekError
+	if !errors.As(err, &peekErr) {
+		t.Fatalf("Expected a BOMPeekError, got %v", err)
+	}
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Equal(t, 1, peekErr.N)
+	assert.Equal(t, []byte{0xFF}, peekErr.Partial)
+	assert.False(t, peekErr.Truncated)
+}
+
+// TestPeekFailureTruncated tests that a source ending prematurely in the middle of the BOM is reported as truncated.
+func TestPeekFailureTruncated(t *testing.T) {
+	// The source hands out the first BOM byte, then reports that it was cut off
+	reader := iotest.DataErrReader(io.MultiReader(bytes.NewReader([]byte{0xFF}), iotest.ErrReader(io.ErrUnexpectedEOF)))
+
+	_, err := unutf16.NewReader(reader).Read(make([]byte, 10))
+
+	var peekErr *unutf16.BOMPeekError
+	if !errors.As(err, &peekErr) {
+		t.Fatalf("Expected a BOMPeekError, got %v", err)
+	}
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.True(t, peekErr.Truncated)
+	assert.Equal(t, []byte{0xFF}, peekErr.Partial)
+}
+
+// TestPeekExhausted tests that a source ending in the middle of the BOM is not an error.
+func TestPeekExhausted(t *testing.T) {
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte{0xFF})))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, []byte{0xFF}, output)
+}
+
+// TestPeekDeadline tests that a read deadline of the source interrupts the peek, which resumes on the next Read.
+func TestPeekDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// The first BOM byte arrives in time, the rest only after the deadline
+	go server.Write([]byte{0xFF})
+	err := client.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Error setting read deadline: %v", err)
+	}
+
+	utf8Reader := unutf16.NewReader(client)
+	_, err = utf8Reader.Read(make([]byte, 10))
+
+	var peekErr *unutf16.BOMPeekError
+	if !errors.As(err, &peekErr) {
+		t.Fatalf("Expected a BOMPeekError, got %v", err)
+	}
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.Equal(t, []byte{0xFF}, peekErr.Partial)
+
+	// Once the deadline is lifted, detection continues with the byte read before
+	err = client.SetReadDeadline(time.Time{})
+	if err != nil {
+		t.Fatalf("Error setting read deadline: %v", err)
+	}
+	go func() {
+		server.Write([]byte{0xFE, 0x68, 0x00, 0x69, 0x00})
+		server.Close()
+	}()
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hi", string(output))
+	assert.Equal(t, unutf16.EncodingUTF16LE, utf8Reader.DetectedEncoding())
+}
+
+// TestReadDeadline tests that a read deadline of the source expiring after detection is returned as is.
+func TestReadDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go server.Write([]byte{0xFF, 0xFE, 0x68, 0x00})
+
+	utf8Reader := unutf16.NewReader(client)
+	buffer := make([]byte, 10)
+	n, err := utf8Reader.Read(buffer)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+	assert.Equal(t, "h", string(buffer[:n]))
+
+	err = client.SetReadDeadline(time.Now())
+	if err != nil {
+		t.Fatalf("Error setting read deadline: %v", err)
+	}
+
+	_, err = utf8Reader.Read(buffer)
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	var peekErr *unutf16.BOMPeekError
+	assert.False(t, errors.As(err, &peekErr))
+}
+
 var simulatedError = errors.New("simulated read error")
 
 type errorReader struct{}
@@ -101,3 +1573,286 @@ type errorReader struct{}
 func (e *errorReader) Read(p []byte) (int, error) {
 	return 0, simulatedError
 }
+
+// FuzzReader tests that arbitrary input never makes the Reader panic, and that
+// it always produces valid UTF-8 unless the input is passed through.
+func FuzzReader(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0xFF})
+	f.Add([]byte{0xFF, 0xFE})
+	f.Add([]byte{0xFF, 0xFE, 0x00})
+	f.Add([]byte{0xFF, 0xFE, 0x00, 0xDC, 0x3D, 0xD8})
+	f.Add([]byte{0xFE, 0xFF, 0xD8, 0x3D})
+	f.Add([]byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x11, 0x00, 0x00})
+	f.Add([]byte{0xEF, 0xBB, 0xBF, 0x68})
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		utf8Reader := unutf16.NewReader(bytes.NewReader(input))
+		output, err := io.ReadAll(utf8Reader)
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+
+		if utf8Reader.DetectedEncoding() != unutf16.EncodingPassthrough && utf8Reader.DetectedEncoding() != unutf16.EncodingUTF8BOM {
+			assert.True(t, utf8.Valid(output), "output %x", output)
+		}
+	})
+}
+
+// stubbornReader hands out its data, then keeps returning 0, nil forever.
+type stubbornReader struct {
+	data []byte
+}
+
+func (s *stubbornReader) Read(p []byte) (int, error) {
+	n := copy(p, s.data)
+	s.data = s.data[n:]
+	return n, nil
+}
+
+// writerToRecorder records whether its WriteTo method was used.
+type writerToRecorder struct {
+	*bytes.Reader
+	used bool
+}
+
+func (w *writerToRecorder) WriteTo(dst io.Writer) (int64, error) {
+	w.used = true
+	return w.Reader.WriteTo(dst)
+}
+
+// readerFromRecorder records whether its ReadFrom method was used.
+type readerFromRecorder struct {
+	bytes.Buffer
+	used bool
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.used = true
+	return r.Buffer.ReadFrom(src)
+}
+
+type errorReaderAt struct{}
+
+func (e *errorReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	return 0, simulatedError
+}
+
+// benchmarkUTF16LE returns roughly 1MB of UTF-16LE encoded text prefixed with a BOM.
+func benchmarkUTF16LE() []byte {
+	data := []byte{0xFF, 0xFE}
+	for len(data) < 1<<20 {
+		data = append(data, 0x68, 0x00, 0xE9, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00, 0x20, 0x00)
+	}
+	return data
+}
+
+// benchmarkASCIIUTF16LE returns roughly 1MB of UTF-16LE encoded ASCII text prefixed with a BOM.
+func benchmarkASCIIUTF16LE() []byte {
+	data := []byte{0xFF, 0xFE}
+	for len(data) < 1<<20 {
+		data = append(data, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00, 0x20, 0x00)
+	}
+	return data
+}
+
+// BenchmarkCopy compares io.Copy through WriteTo with io.Copy through Read.
+func BenchmarkCopy(b *testing.B) {
+	data := benchmarkUTF16LE()
+	// Hide io.Discard's ReadFrom so neither side gets to use its pooled buffer
+	discard := struct{ io.Writer }{io.Discard}
+
+	b.Run("WriteTo", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			_, err := io.Copy(discard, unutf16.NewReader(bytes.NewReader(data)))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Read", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			// Hide WriteTo so io.Copy has to fall back to Read
+			_, err := io.Copy(discard, struct{ io.Reader }{unutf16.NewReader(bytes.NewReader(data))})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// eofReader hands out one chunk per Read, returning io.EOF along with the last chunk.
+type eofReader struct {
+	chunks [][]byte
+}
+
+func (e *eofReader) Read(p []byte) (int, error) {
+	if len(e.chunks) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, e.chunks[0])
+	e.chunks[0] = e.chunks[0][n:]
+	if len(e.chunks[0]) == 0 {
+		e.chunks = e.chunks[1:]
+	}
+	if len(e.chunks) == 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// gatedReader blocks until gate is closed, then hands out its data and closes drained
+// once it returns io.EOF.
+type gatedReader struct {
+	data    []byte
+	gate    chan struct{}
+	drained chan struct{}
+}
+
+func (g *gatedReader) Read(p []byte) (int, error) {
+	<-g.gate
+	if len(g.data) == 0 {
+		close(g.drained)
+		return 0, io.EOF
+	}
+
+	n := copy(p, g.data)
+	g.data = g.data[n:]
+	return n, nil
+}
+
+// deadlineReader blocks until a read deadline in the past is set, then fails with
+// os.ErrDeadlineExceeded like a net.Conn does.
+type deadlineReader struct {
+	once    sync.Once
+	expired chan struct{}
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	<-d.expired
+	return 0, os.ErrDeadlineExceeded
+}
+
+func (d *deadlineReader) SetReadDeadline(t time.Time) error {
+	if !t.After(time.Now()) {
+		d.once.Do(func() { close(d.expired) })
+	}
+	return nil
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+// BenchmarkBufferSize compares the throughput of io.Copy at different buffer sizes.
+func BenchmarkBufferSize(b *testing.B) {
+	data := benchmarkUTF16LE()
+	discard := struct{ io.Writer }{io.Discard}
+
+	for _, size := range []int{512, 4 * 1024, 32 * 1024, 256 * 1024} {
+		b.Run(fmt.Sprintf("%dB", size), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				_, err := io.Copy(discard, unutf16.NewReader(bytes.NewReader(data), unutf16.WithBufferSize(size)))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPassthrough compares small reads of UTF-8 input with reading through an io.MultiReader.
+func BenchmarkPassthrough(b *testing.B) {
+	data := bytes.Repeat([]byte("hello world "), 1<<20/12)
+	buffer := make([]byte, 512)
+
+	drain := func(b *testing.B, r io.Reader) {
+		for {
+			_, err := r.Read(buffer)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("Reader", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			drain(b, unutf16.NewReader(bytes.NewReader(data)))
+		}
+	})
+
+	b.Run("MultiReader", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			// Stitching a peeked prefix back like the Reader did before
+			source := bytes.NewReader(data)
+			prefix := make([]byte, 4)
+			_, _ = io.ReadFull(source, prefix)
+			drain(b, io.MultiReader(bytes.NewReader(prefix), source))
+		}
+	})
+}
+
+// BenchmarkSmallReads compares reading 1 MiB of UTF-16LE in 64 byte chunks from a Reader, whose
+// decoder copies runs of ASCII in one go, with reading it from the UTF-16 decoder of x/text, and
+// with reading it from a Reader in 32 KiB chunks, for ASCII text as well as text mixed with "é".
+func BenchmarkSmallReads(b *testing.B) {
+	inputs := []struct {
+		name string
+		data []byte
+	}{
+		{name: "ascii", data: benchmarkASCIIUTF16LE()},
+		{name: "mixed", data: benchmarkUTF16LE()},
+	}
+	readers := []struct {
+		name   string
+		size   int
+		reader func(data []byte) io.Reader
+	}{
+		{name: "xtext/64B", size: 64, reader: func(data []byte) io.Reader {
+			return transform.NewReader(bytes.NewReader(data), unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder())
+		}},
+		{name: "Reader/64B", size: 64, reader: func(data []byte) io.Reader {
+			return unutf16.NewReader(bytes.NewReader(data))
+		}},
+		{name: "Reader/32KiB", size: 32 * 1024, reader: func(data []byte) io.Reader {
+			return unutf16.NewReader(bytes.NewReader(data))
+		}},
+	}
+
+	for _, input := range inputs {
+		for _, rr := range readers {
+			b.Run(input.name+"/"+rr.name, func(b *testing.B) {
+				buffer := make([]byte, rr.size)
+				b.SetBytes(int64(len(input.data)))
+				for i := 0; i < b.N; i++ {
+					utf8Reader := rr.reader(input.data)
+					for {
+						_, err := utf8Reader.Read(buffer)
+						if err == io.EOF {
+							break
+						}
+						if err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+			})
+		}
+	}
+}
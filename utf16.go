@@ -0,0 +1,263 @@
+package unutf16
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// ErrInvalidSequence is the cause of a DecodeError reporting malformed UTF-16,
+// such as an unpaired surrogate or a dangling byte at the end of the input.
+var ErrInvalidSequence = errors.New("invalid UTF-16 sequence")
+
+// ErrTruncatedSequence is the cause of a DecodeError reporting that UTF-16 input ends
+// in the middle of a code unit, in strict mode. Without strict mode, the dangling byte
+// is decoded as a single replacement rune followed by a clean end of the output.
+// It wraps ErrInvalidSequence, so it matches that as well.
+var ErrTruncatedSequence = fmt.Errorf("UTF-16 input ends in the middle of a code unit: %w", ErrInvalidSequence)
+
+// ErrOddLength is returned when UTF-16 input ends on an odd byte boundary
+// and the Reader was created with WithRejectOddLength.
+var ErrOddLength = errors.New("UTF-16 input has an odd number of bytes")
+
+// SurrogatePolicy determines how unpaired surrogates in UTF-16 input are decoded.
+type SurrogatePolicy int
+
+const (
+	// SurrogateReplace replaces an unpaired surrogate with U+FFFD, or the rune set
+	// with WithReplacement. This is the default.
+	SurrogateReplace SurrogatePolicy = iota
+	// SurrogateError reports an unpaired surrogate as a DecodeError.
+	SurrogateError
+	// SurrogatePassthrough encodes an unpaired surrogate as is, which produces WTF-8
+	// rather than valid UTF-8. This preserves data such as Windows file names verbatim.
+	SurrogatePassthrough
+)
+
+// utf16Decoder is a transform.Transformer that converts UTF-16 without a BOM to UTF-8.
+// By default it decodes like unicode/utf16.Decode and replaces each malformed code
+// unit with the replacement rune, but in strict mode it reports a DecodeError instead.
+type utf16Decoder struct {
+	endianness  unicode.Endianness // Byte order of the code units
+	strict      bool               // Report malformed input instead of replacing it
+	replacement rune               // Rune substituted for malformed input
+	rejectOdd   bool               // Report a dangling trailing byte as ErrOddLength
+	surrogates  SurrogatePolicy    // How to decode unpaired surrogates
+	start       int64              // Position of the first input byte within the stream
+	offset      int64              // Position of the next input byte within the stream
+	produced    int64              // Number of output bytes produced so far
+	offsets     *OffsetMap         // Records checkpoints every so many runes, if not nil
+	runes       int64              // Number of runes produced so far, counted only for offsets
+
+	truncatedTail *bool // Set if the input ends in a high surrogate without its low surrogate, if not nil
+
+	swapCheck func() // Called if the code units following the BOM look byte-swapped, if not nil
+	checked   int    // Number of code units following the BOM checked so far
+	swapped   int    // Number of the checked code units that look byte-swapped
+}
+
+// Reset implements the transform.Transformer interface.
+func (d *utf16Decoder) Reset() {
+	d.offset = d.start
+	d.produced = 0
+	d.runes = 0
+	d.checked, d.swapped = 0, 0
+}
+
+// Transform implements the transform.Transformer interface.
+func (d *utf16Decoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	// Keep track of the positions within the stream and the output for error reporting
+	defer func() {
+		d.offset += int64(nSrc)
+		d.produced += int64(nDst)
+	}()
+
+	for nSrc < len(src) {
+		// Runs without surrogates are decoded in one go unless each code unit has to be inspected or counted
+		if d.offsets == nil && (d.swapCheck == nil || d.checked >= swapCheckUnits) {
+			if m, n := d.plain(dst[nDst:], src[nSrc:]); n > 0 {
+				nDst += m
+				nSrc += n
+				continue
+			}
+		}
+
+		// ASCII is by far the most common, so it skips the checks needed for everything else
+		if nSrc+2 <= len(src) {
+			if unit := d.unit(src[nSrc:]); unit < utf8.RuneSelf {
+				if nDst >= len(dst) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				dst[nDst] = byte(unit)
+				nDst++
+				nSrc += 2
+				d.inspect(unit)
+				d.checkpoint(nSrc, nDst)
+				continue
+			}
+		}
+
+		r, size, valid := utf8.RuneError, 0, true
+		// Code unit of an unpaired surrogate, if that is what makes the input invalid
+		var surrogate uint16
+		// The input ends in the middle of a code unit
+		var truncated bool
+
+		switch remaining := src[nSrc:]; {
+		case len(remaining) < 2:
+			if !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			// Single trailing byte
+			if d.rejectOdd {
+				return nDst, nSrc, ErrOddLength
+			}
+			size, valid, truncated = 1, false, true
+		case !utf16.IsSurrogate(rune(d.unit(remaining))):
+			r, size = rune(d.unit(remaining)), 2
+		case d.unit(remaining) >= 0xDC00:
+			// Low surrogate without a preceding high surrogate
+			size, valid, surrogate = 2, false, d.unit(remaining)
+		case len(remaining) < 4:
+			if !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			// High surrogate without the low surrogate it requires, since the input is cut off
+			size, valid, surrogate = 2, false, d.unit(remaining)
+			if d.truncatedTail != nil {
+				*d.truncatedTail = true
+			}
+		default:
+			r = utf16.DecodeRune(rune(d.unit(remaining)), rune(d.unit(remaining[2:])))
+			size = 4
+			if r == utf8.RuneError {
+				// High surrogate followed by something else, which is decoded on its own
+				size, valid, surrogate = 2, false, d.unit(remaining)
+			}
+		}
+
+		if surrogate != 0 && d.surrogates == SurrogatePassthrough {
+			// The generalized UTF-8 encoding of a surrogate, which utf8.EncodeRune refuses
+			if nDst+3 > len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = 0xE0 | byte(surrogate>>12)
+			dst[nDst+1] = 0x80 | byte(surrogate>>6)&0x3F
+			dst[nDst+2] = 0x80 | byte(surrogate)&0x3F
+			nDst += 3
+			d.inspect(surrogate)
+			nSrc += size
+			d.checkpoint(nSrc, nDst)
+			continue
+		}
+		if !valid {
+			switch {
+			case truncated && d.strict:
+				return nDst, nSrc, d.error(ErrTruncatedSequence, nSrc, nDst)
+			case d.strict && d.surrogates == SurrogateReplace || surrogate != 0 && d.surrogates == SurrogateError:
+				return nDst, nSrc, d.error(ErrInvalidSequence, nSrc, nDst)
+			}
+			r = d.replacement
+		}
+		if nDst+utf8.RuneLen(r) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+		if size >= 2 {
+			d.inspect(d.unit(src[nSrc:]))
+		}
+		nSrc += size
+		d.checkpoint(nSrc, nDst)
+	}
+	return nDst, nSrc, nil
+}
+
+// plain decodes the leading code units of src that are not surrogates, which are always
+// valid, into dst as long as they fit, and returns the number of bytes written and read.
+func (d *utf16Decoder) plain(dst, src []byte) (nDst, nSrc int) {
+	// Index of the low byte of a code unit
+	low := 0
+	if d.endianness == unicode.BigEndian {
+		low = 1
+	}
+
+	for nSrc+2 <= len(src) {
+		unit := uint16(src[nSrc+low]) | uint16(src[nSrc+1-low])<<8
+		switch {
+		case unit < utf8.RuneSelf:
+			if nDst+1 > len(dst) {
+				return nDst, nSrc
+			}
+			dst[nDst] = byte(unit)
+			nDst++
+		case unit < 0x800:
+			if nDst+2 > len(dst) {
+				return nDst, nSrc
+			}
+			dst[nDst] = 0xC0 | byte(unit>>6)
+			dst[nDst+1] = 0x80 | byte(unit)&0x3F
+			nDst += 2
+		case utf16.IsSurrogate(rune(unit)):
+			return nDst, nSrc
+		default:
+			if nDst+3 > len(dst) {
+				return nDst, nSrc
+			}
+			dst[nDst] = 0xE0 | byte(unit>>12)
+			dst[nDst+1] = 0x80 | byte(unit>>6)&0x3F
+			dst[nDst+2] = 0x80 | byte(unit)&0x3F
+			nDst += 3
+		}
+		nSrc += 2
+	}
+	return nDst, nSrc
+}
+
+// error returns a DecodeError for malformed input at nSrc, where the current Transform
+// call has produced nDst bytes.
+func (d *utf16Decoder) error(cause error, nSrc, nDst int) error {
+	return &DecodeError{
+		Cause:          cause,
+		Offset:         d.offset + int64(nSrc),
+		ProducedOffset: d.produced + int64(nDst),
+	}
+}
+
+// inspect checks whether one of the first code units following the BOM looks byte-swapped,
+// and calls swapCheck once enough of them have been checked if most of them do.
+func (d *utf16Decoder) inspect(unit uint16) {
+	if d.swapCheck == nil || d.checked >= swapCheckUnits {
+		return
+	}
+	d.checked++
+	if !printableASCII(unit) && printableASCII(unit>>8|unit<<8) {
+		d.swapped++
+	}
+	if d.checked == swapCheckUnits && d.swapped >= swapCheckMin {
+		d.swapCheck()
+	}
+}
+
+// checkpoint counts a rune that ended at nSrc and nDst of the current Transform call,
+// and records a checkpoint after every interval of runes if there is an OffsetMap.
+func (d *utf16Decoder) checkpoint(nSrc, nDst int) {
+	if d.offsets == nil {
+		return
+	}
+	d.runes++
+	if d.runes%d.offsets.interval() == 0 {
+		d.offsets.record(d.offset+int64(nSrc), d.produced+int64(nDst))
+	}
+}
+
+// unit returns the code unit stored in the first two bytes of b.
+func (d *utf16Decoder) unit(b []byte) uint16 {
+	if d.endianness == unicode.LittleEndian {
+		return uint16(b[0]) | uint16(b[1])<<8
+	}
+	return uint16(b[0])<<8 | uint16(b[1])
+}
@@ -0,0 +1,365 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestUTF16MatchesStdlib tests that lenient decoding produces the same output as unicode/utf16.
+func TestUTF16MatchesStdlib(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	// Bias the input towards surrogates to cover the interesting cases
+	units := []byte{0x00, 0x41, 0xD8, 0xDB, 0xDC, 0xDF, 0xFF}
+
+	for i := 0; i < 1000; i++ {
+		input := make([]byte, random.Intn(16))
+		for j := range input {
+			input[j] = units[random.Intn(len(units))]
+		}
+
+		for _, endianness := range []unicode.Endianness{unicode.LittleEndian, unicode.BigEndian} {
+			codeUnits := make([]uint16, len(input)/2)
+			for j := range codeUnits {
+				if endianness == unicode.LittleEndian {
+					codeUnits[j] = binary.LittleEndian.Uint16(input[2*j:])
+				} else {
+					codeUnits[j] = binary.BigEndian.Uint16(input[2*j:])
+				}
+			}
+			expected := string(utf16.Decode(codeUnits))
+			if len(input)%2 != 0 {
+				// A dangling byte is replaced as well
+				expected += string(utf8.RuneError)
+			}
+
+			// The input never starts with a BOM, so the default endianness applies
+			output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(input), unutf16.WithDefaultEndianness(endianness)))
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, expected, string(output), "input %x", input)
+		}
+	}
+}
+
+// TestUTF16LongRuns tests that long runs of multi-byte characters decode correctly where their output does not fit in one buffer.
+func TestUTF16LongRuns(t *testing.T) {
+	// Runs of 1, 2 and 3 bytes of output per code unit, with a surrogate pair in between
+	expected := strings.Repeat("a", 1000) + strings.Repeat("é", 3000) + "😀" + strings.Repeat("€", 3000) + "z"
+
+	for _, endianness := range []unicode.Endianness{unicode.LittleEndian, unicode.BigEndian} {
+		var input []byte
+		for _, unit := range utf16.Encode([]rune(expected)) {
+			if endianness == unicode.LittleEndian {
+				input = binary.LittleEndian.AppendUint16(input, unit)
+			} else {
+				input = binary.BigEndian.AppendUint16(input, unit)
+			}
+		}
+
+		output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(input), unutf16.WithDefaultEndianness(endianness)))
+		if err != nil {
+			t.Fatalf("Error reading from UTF8 reader: %v", err)
+		}
+
+		assert.Equal(t, expected, string(output))
+	}
+}
+
+// TestStrict tests that strict mode reports malformed UTF-16 as a DecodeError.
+func TestStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  []byte
+		offset int64
+	}{
+		// BOM + "h" + lone low surrogate
+		{name: "lone low surrogate", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x00, 0xDC}, offset: 4},
+		// BOM + "h" + high surrogate + "i"
+		{name: "unpaired high surrogate", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x00, 0xD8, 0x69, 0x00}, offset: 4},
+		// BOM + "h" + high surrogate at end of input
+		{name: "truncated surrogate pair", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x00, 0xD8}, offset: 4},
+		// "h" + lone low surrogate without BOM
+		{name: "without bom", input: []byte{0x68, 0x00, 0x00, 0xDC}, offset: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []unutf16.Option{unutf16.WithStrict(), unutf16.WithDefaultEndianness(unicode.LittleEndian)}
+			output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(tt.input), opts...))
+
+			var decodeErr *unutf16.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Expected a DecodeError, got %v", err)
+			}
+			assert.ErrorIs(t, err, unutf16.ErrInvalidSequence)
+			assert.Equal(t, tt.offset, decodeErr.Offset)
+			assert.Equal(t, int64(1), decodeErr.ProducedOffset)
+			assert.Equal(t, fmt.Sprintf("failed to decode input at offset %d: invalid UTF-16 sequence", tt.offset), err.Error())
+			assert.Equal(t, "h", string(output))
+		})
+	}
+}
+
+// TestStrictValidInput tests that strict mode decodes well-formed UTF-16 including surrogate pairs.
+func TestStrictValidInput(t *testing.T) {
+	// UTF-16LE data (BOM + "h" + U+1F44B)
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x3D, 0xD8, 0x4B, 0xDC}
+
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithStrict()))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "h👋", string(output))
+}
+
+// TestReplacement tests that malformed UTF-16 is replaced with the configured rune.
+func TestReplacement(t *testing.T) {
+	// UTF-16LE data (BOM + "h" + lone low surrogate + "i" + dangling byte)
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x00, 0xDC, 0x69, 0x00, 0x6A}
+
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithReplacement('?')))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "h?i?", string(output))
+}
+
+// TestSurrogatePolicy tests that unpaired surrogates are decoded according to the policy.
+func TestSurrogatePolicy(t *testing.T) {
+	// UTF-16LE data (BOM + "h" + lone low surrogate + "i" + unpaired high surrogate)
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x00, 0xDC, 0x69, 0x00, 0x3D, 0xD8}
+
+	tests := []struct {
+		name     string
+		opts     []unutf16.Option
+		expected string
+		err      bool
+	}{
+		{name: "default", expected: "h\uFFFDi\uFFFD"},
+		{name: "replace", opts: []unutf16.Option{unutf16.WithSurrogatePolicy(unutf16.SurrogateReplace), unutf16.WithReplacement('?')}, expected: "h?i?"},
+		{name: "error", opts: []unutf16.Option{unutf16.WithSurrogatePolicy(unutf16.SurrogateError)}, expected: "h", err: true},
+		{name: "passthrough", opts: []unutf16.Option{unutf16.WithSurrogatePolicy(unutf16.SurrogatePassthrough)}, expected: "h\xED\xB0\x80i\xED\xA0\xBD"},
+		{name: "strict", opts: []unutf16.Option{unutf16.WithStrict(), unutf16.WithSurrogatePolicy(unutf16.SurrogateReplace)}, expected: "h", err: true},
+		{name: "strict passthrough", opts: []unutf16.Option{unutf16.WithStrict(), unutf16.WithSurrogatePolicy(unutf16.SurrogatePassthrough)}, expected: "h\xED\xB0\x80i\xED\xA0\xBD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), tt.opts...))
+			if tt.err {
+				assert.IsType(t, new(unutf16.DecodeError), err)
+				assert.ErrorIs(t, err, unutf16.ErrInvalidSequence)
+			} else if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestSurrogatePolicyOtherInput tests that the policy does not apply to a dangling trailing byte.
+func TestSurrogatePolicyOtherInput(t *testing.T) {
+	// UTF-16LE data (BOM + "h" + dangling byte)
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x69}
+
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithSurrogatePolicy(unutf16.SurrogateError)))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "h\uFFFD", string(output))
+}
+
+// TestSurrogatePolicyConfigError tests that an unknown surrogate policy is rejected.
+func TestSurrogatePolicyConfigError(t *testing.T) {
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")), unutf16.WithSurrogatePolicy(42))
+
+	_, err := utf8Reader.Read(make([]byte, 10))
+	assert.IsType(t, new(unutf16.ConfigError), err)
+	assert.EqualError(t, err, "invalid configuration: surrogate policy 42 is unknown")
+}
+
+// TestReplacementConfigError tests that invalid replacement configurations are rejected.
+func TestReplacementConfigError(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []unutf16.Option
+		expected string
+	}{
+		{
+			name:     "with strict",
+			opts:     []unutf16.Option{unutf16.WithStrict(), unutf16.WithReplacement('?')},
+			expected: "invalid configuration: WithStrict and WithReplacement are mutually exclusive",
+		},
+		{
+			name:     "invalid rune",
+			opts:     []unutf16.Option{unutf16.WithReplacement(0xD800)},
+			expected: "invalid configuration: replacement U+D800 is not a valid rune",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader([]byte("hello")), tt.opts...)
+
+			_, err := utf8Reader.Read(make([]byte, 10))
+			assert.IsType(t, new(unutf16.ConfigError), err)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestTruncatedCodeUnit tests that input ending in the middle of a code unit decodes to a single replacement rune.
+func TestTruncatedCodeUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+	}{
+		// BOM + "h" + dangling byte
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69}, expected: "h\uFFFD"},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00}, expected: "h\uFFFD"},
+		{name: "replacement", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69}, opts: []unutf16.Option{unutf16.WithReplacement('?')}, expected: "h?"},
+		{name: "surrogate policy", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69}, opts: []unutf16.Option{unutf16.WithSurrogatePolicy(unutf16.SurrogatePassthrough)}, expected: "h\uFFFD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read one byte at a time so the dangling byte arrives on its own
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), tt.opts...)
+
+			output, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestTruncatedCodeUnitStrict tests that strict mode reports input ending in the middle of a code unit as ErrTruncatedSequence.
+func TestTruncatedCodeUnitStrict(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		opts  []unutf16.Option
+	}{
+		// BOM + "h" + dangling byte
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69}},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00}},
+		{name: "surrogate policy", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69}, opts: []unutf16.Option{unutf16.WithSurrogatePolicy(unutf16.SurrogatePassthrough)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]unutf16.Option{unutf16.WithStrict()}, tt.opts...)
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), opts...)
+
+			output, err := io.ReadAll(utf8Reader)
+
+			var decodeErr *unutf16.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Expected a DecodeError, got %v", err)
+			}
+			assert.ErrorIs(t, err, unutf16.ErrTruncatedSequence)
+			assert.ErrorIs(t, err, unutf16.ErrInvalidSequence)
+			assert.Equal(t, int64(4), decodeErr.Offset)
+			assert.Equal(t, "failed to decode input at offset 4: UTF-16 input ends in the middle of a code unit: invalid UTF-16 sequence", err.Error())
+			assert.Equal(t, "h", string(output))
+		})
+	}
+}
+
+// TestRejectOddLength tests that truncated UTF-16 input is reported as ErrOddLength.
+func TestRejectOddLength(t *testing.T) {
+	// UTF-16LE data (BOM + "hi" + dangling byte)
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00, 0x6A}
+
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithRejectOddLength()))
+
+	assert.ErrorIs(t, err, unutf16.ErrOddLength)
+	assert.Equal(t, "hi", string(output))
+}
+
+// TestRejectOddLengthPassthrough tests that odd-length input which is not UTF-16 is passed through.
+func TestRejectOddLengthPassthrough(t *testing.T) {
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte("hello")), unutf16.WithRejectOddLength()))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hello", string(output))
+}
+
+// TestStrictOffsetAcrossReads tests that the error offset counts input from earlier reads.
+func TestStrictOffsetAcrossReads(t *testing.T) {
+	// UTF-16LE data (BOM + 3000 times "h" + lone low surrogate), larger than the decoder's buffer
+	utf16leData := []byte{0xFF, 0xFE}
+	for i := 0; i < 3000; i++ {
+		utf16leData = append(utf16leData, 0x68, 0x00)
+	}
+	utf16leData = append(utf16leData, 0x00, 0xDC)
+
+	_, err := io.ReadAll(unutf16.NewReader(iotest.HalfReader(bytes.NewReader(utf16leData)), unutf16.WithStrict()))
+
+	var decodeErr *unutf16.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected a DecodeError, got %v", err)
+	}
+	assert.Equal(t, int64(6002), decodeErr.Offset)
+	assert.Equal(t, int64(3000), decodeErr.ProducedOffset)
+}
+
+// TestStrictProducedOffset tests that the error reports how much output was produced before a truncated surrogate pair.
+func TestStrictProducedOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		offset   int64
+		produced int64
+	}{
+		// BOM + "hé" + high surrogate at end of input
+		{name: "after two byte rune", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0x3D, 0xD8}, offset: 6, produced: 3},
+		// BOM + U+1F44B + high surrogate at end of input
+		{name: "after surrogate pair", input: []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x4B, 0xDC, 0x3D, 0xD8}, offset: 6, produced: 4},
+		// BOM + "€" + high surrogate + "i"
+		{name: "before other unit", input: []byte{0xFF, 0xFE, 0xAC, 0x20, 0x3D, 0xD8, 0x69, 0x00}, offset: 4, produced: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Read one byte at a time so the output is produced across many transforms
+			utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(tt.input)), unutf16.WithStrict())
+			output, err := io.ReadAll(utf8Reader)
+
+			var decodeErr *unutf16.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("Expected a DecodeError, got %v", err)
+			}
+			assert.Equal(t, tt.offset, decodeErr.Offset)
+			assert.Equal(t, tt.produced, decodeErr.ProducedOffset)
+			assert.Len(t, output, int(tt.produced))
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package unutf16
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// ErrInvalidOutput is the cause of a DecodeError reporting that a decoder produced
+// output that is not valid UTF-8, which is only checked with WithValidateOutput.
+// Unlike for other causes, the Offset of the DecodeError counts decoded output bytes,
+// so it equals the ProducedOffset.
+var ErrInvalidOutput = errors.New("decoded output is not valid UTF-8")
+
+// ErrTooManyReplacements is returned by a Reader created with WithMaxReplacementRatio
+// when too many of the runes it decoded are replacement characters.
+var ErrTooManyReplacements = errors.New("too many replacement characters")
+
+// runeErrorBytes is the UTF-8 encoding of U+FFFD.
+var runeErrorBytes = []byte(string(utf8.RuneError))
+
+// replacementSample is the number of runes decoded before WithMaxReplacementRatio
+// judges the ratio of replacement characters, so that a few early ones do not count
+// for too much.
+const replacementSample = 64
+
+// utf8Validator is a transform.Transformer that relays UTF-8 unchanged, but reports
+// a DecodeError as soon as it sees a byte sequence that is not valid UTF-8.
+// It holds back a rune that is split across chunks until it is complete.
+type utf8Validator struct {
+	offset int64 // Position of the next byte within the output
+}
+
+// Reset implements the transform.Transformer interface.
+func (v *utf8Validator) Reset() {
+	v.offset = 0
+}
+
+// Transform implements the transform.Transformer interface.
+func (v *utf8Validator) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	// Keep track of the position within the output for error reporting
+	defer func() {
+		v.offset += int64(nSrc)
+	}()
+
+	for nSrc < len(src) {
+		size := 1
+		if src[nSrc] >= utf8.RuneSelf {
+			if !utf8.FullRune(src[nSrc:]) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			var r rune
+			r, size = utf8.DecodeRune(src[nSrc:])
+			if r == utf8.RuneError && size == 1 {
+				return nDst, nSrc, &DecodeError{
+					Cause:          ErrInvalidOutput,
+					Offset:         v.offset + int64(nSrc),
+					ProducedOffset: v.offset + int64(nSrc),
+				}
+			}
+		}
+
+		if nDst+size > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], src[nSrc:nSrc+size])
+		nSrc += size
+	}
+	return nDst, nSrc, nil
+}
+
+// replacementLimiter is a transform.Transformer that relays UTF-8 unchanged, but fails
+// once the share of replacement characters among the runes it has seen exceeds maxRatio.
+// It holds back a rune that is split across chunks until it is complete.
+type replacementLimiter struct {
+	replacement  rune    // Rune the decoder substitutes for malformed input
+	maxRatio     float64 // Largest share of replacement characters tolerated
+	runes        int64   // Number of runes seen so far
+	replacements int64   // Number of replacement characters seen so far
+}
+
+// Reset implements the transform.Transformer interface.
+func (l *replacementLimiter) Reset() {
+	l.runes, l.replacements = 0, 0
+}
+
+// Transform implements the transform.Transformer interface.
+func (l *replacementLimiter) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		r, size := rune(src[nSrc]), 1
+		if r >= utf8.RuneSelf {
+			if !utf8.FullRune(src[nSrc:]) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			r, size = utf8.DecodeRune(src[nSrc:])
+		}
+
+		if nDst+size > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], src[nSrc:nSrc+size])
+		nSrc += size
+
+		l.runes++
+		if r == l.replacement {
+			l.replacements++
+		}
+		if l.runes >= replacementSample && l.exceeded() {
+			return nDst, nSrc, l.err()
+		}
+	}
+
+	// Input shorter than the sample is judged as a whole
+	if atEOF && l.runes < replacementSample && l.exceeded() {
+		return nDst, nSrc, l.err()
+	}
+	return nDst, nSrc, nil
+}
+
+// exceeded reports whether the share of replacement characters is above the maximum.
+func (l *replacementLimiter) exceeded() bool {
+	return float64(l.replacements) > l.maxRatio*float64(l.runes)
+}
+
+// err returns the error reporting the replacement characters seen so far.
+func (l *replacementLimiter) err() error {
+	return fmt.Errorf("%d of %d runes are replacement characters: %w", l.replacements, l.runes, ErrTooManyReplacements)
+}
+
+// replacementSubstituter is a transform.Transformer that substitutes a string for every
+// U+FFFD in UTF-8 text. It holds back the start of a U+FFFD that is split across chunks
+// until it is complete, and writes a string that does not fit the destination in parts.
+type replacementSubstituter struct {
+	replacement string // String substituted for U+FFFD
+	written     int    // Bytes of the string written for the U+FFFD at the start of the source
+}
+
+// Reset implements the transform.Transformer interface.
+func (s *replacementSubstituter) Reset() {
+	s.written = 0
+}
+
+// Transform implements the transform.Transformer interface.
+func (s *replacementSubstituter) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if rest := src[nSrc:]; rest[0] == runeErrorBytes[0] {
+			if len(rest) < len(runeErrorBytes) && !atEOF && bytes.HasPrefix(runeErrorBytes, rest) {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if bytes.HasPrefix(rest, runeErrorBytes) {
+				// The U+FFFD is only consumed once all of the string has been written
+				n := copy(dst[nDst:], s.replacement[s.written:])
+				nDst += n
+				if s.written += n; s.written < len(s.replacement) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				s.written = 0
+				nSrc += len(runeErrorBytes)
+				continue
+			}
+		}
+
+		if nDst >= len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		dst[nDst] = src[nSrc]
+		nDst++
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
@@ -0,0 +1,207 @@
+package unutf16_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/transform"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestValidateOutput tests that valid decoded output passes the check, even with runes split across reads
+func TestValidateOutput(t *testing.T) {
+	// UTF-16LE data (BOM + "hé👋")
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00, 0x3D, 0xD8, 0x4B, 0xDC}
+
+	utf8Reader := unutf16.NewReader(iotest.OneByteReader(bytes.NewReader(utf16leData)), unutf16.WithValidateOutput())
+	output, err := io.ReadAll(iotest.OneByteReader(utf8Reader))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "hé👋", string(output))
+}
+
+// TestValidateOutputInvalid tests that invalid output of a decoder is reported as a DecodeError
+func TestValidateOutputInvalid(t *testing.T) {
+	// UTF-16LE data (BOM + "hé"), which a broken decoder relays as is
+	utf16leData := []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}
+
+	broken := func() transform.Transformer {
+		return transform.Nop
+	}
+	opts := []unutf16.Option{unutf16.WithDecoderFor(unutf16.EncodingUTF16LE, broken), unutf16.WithValidateOutput()}
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), opts...))
+
+	var decodeErr *unutf16.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected a DecodeError, got %v", err)
+	}
+	assert.ErrorIs(t, err, unutf16.ErrInvalidOutput)
+	assert.Equal(t, int64(2), decodeErr.Offset)
+	assert.Equal(t, int64(2), decodeErr.ProducedOffset)
+	assert.Equal(t, "h\x00", string(output))
+}
+
+// TestValidateOutputPassthrough tests that input that is passed through is not checked
+func TestValidateOutputPassthrough(t *testing.T) {
+	output, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte("h\xE9")), unutf16.WithValidateOutput()))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, "h\xE9", string(output))
+}
+
+// TestMaxReplacementRatio tests that decoding fails once too many runes are replacement characters
+func TestMaxReplacementRatio(t *testing.T) {
+	// utf16le returns UTF-16LE data (BOM + count runes), where every nth rune is a lone surrogate
+	utf16le := func(count, nth int) []byte {
+		data := []byte{0xFF, 0xFE}
+		for i := 1; i <= count; i++ {
+			if nth > 0 && i%nth == 0 {
+				data = append(data, 0x00, 0xD8)
+			} else {
+				data = append(data, 0x61, 0x00)
+			}
+		}
+		return data
+	}
+	// leading returns UTF-16LE data (BOM + count runes), of which the first bad ones are lone surrogates
+	leading := func(count, bad int) []byte {
+		data := []byte{0xFF, 0xFE}
+		for i := 0; i < count; i++ {
+			if i < bad {
+				data = append(data, 0x00, 0xD8)
+			} else {
+				data = append(data, 0x61, 0x00)
+			}
+		}
+		return data
+	}
+
+	tests := []struct {
+		name        string
+		input       []byte
+		ratio       float64
+		expectedErr error
+	}{
+		{name: "clean", input: utf16le(100, 0), ratio: 0},
+		{name: "below ratio", input: utf16le(100, 20), ratio: 0.1},
+		{name: "above ratio", input: utf16le(100, 5), ratio: 0.1, expectedErr: unutf16.ErrTooManyReplacements},
+		// Judged once the sample is complete, when the leading replacements are diluted enough
+		{name: "leading", input: leading(100, 5), ratio: 0.1},
+		{name: "short", input: utf16le(4, 2), ratio: 0.25, expectedErr: unutf16.ErrTooManyReplacements},
+		{name: "short below ratio", input: utf16le(4, 4), ratio: 0.25},
+		{name: "passthrough", input: []byte("��"), ratio: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), unutf16.WithMaxReplacementRatio(tt.ratio))
+
+			_, err := io.ReadAll(iotest.OneByteReader(utf8Reader))
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+		})
+	}
+}
+
+// TestMaxReplacementRatioMessage tests that the error reports the runes seen so far
+func TestMaxReplacementRatioMessage(t *testing.T) {
+	// UTF-16LE data (BOM + "a" + lone surrogate)
+	utf16leData := []byte{0xFF, 0xFE, 0x61, 0x00, 0x00, 0xD8}
+
+	_, err := io.ReadAll(unutf16.NewReader(bytes.NewReader(utf16leData), unutf16.WithMaxReplacementRatio(0.25)))
+
+	assert.EqualError(t, err, "1 of 2 runes are replacement characters: too many replacement characters")
+}
+
+// TestMaxReplacementRatioConfigError tests that ratios outside of 0 to 1 are rejected
+func TestMaxReplacementRatioConfigError(t *testing.T) {
+	for _, ratio := range []float64{-0.1, 1.5, math.NaN()} {
+		_, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte("hi")), unutf16.WithMaxReplacementRatio(ratio)))
+
+		var configErr *unutf16.ConfigError
+		assert.True(t, errors.As(err, &configErr), "ratio %v", ratio)
+	}
+}
+
+// TestReplacementString tests that every U+FFFD in the output is substituted, even when it is split across reads
+func TestReplacementString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		opts     []unutf16.Option
+		expected string
+	}{
+		// UTF-16LE data (BOM + "a" + lone surrogate + "b" + lone surrogate)
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x61, 0x00, 0x00, 0xD8, 0x62, 0x00, 0x00, 0xDC}, expected: "a[?]b[?]"},
+		// UTF-16LE data (BOM + U+FFFD + "a")
+		{name: "literal", input: []byte{0xFF, 0xFE, 0xFD, 0xFF, 0x61, 0x00}, expected: "[?]a"},
+		{name: "passthrough", input: []byte("a\uFFFDb\xEF\xBF"), expected: "a[?]b\xEF\xBF"},
+		// Bytes that start like U+FFFD but are something else are relayed
+		{name: "lookalike", input: []byte("\uFFFC\xEF\xBF"), expected: "\uFFFC\xEF\xBF"},
+		// UTF-16LE data (BOM + lone surrogate), substituted with a rune first
+		{name: "replacement rune", input: []byte{0xFF, 0xFE, 0x00, 0xD8}, opts: []unutf16.Option{unutf16.WithReplacement('?')}, expected: "?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]unutf16.Option{unutf16.WithReplacementString("[?]")}, tt.opts...)
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), opts...)
+
+			// Read one byte at a time so every U+FFFD ends up split across reads
+			output, err := io.ReadAll(iotest.OneByteReader(utf8Reader))
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+// TestReplacementStringShortDst tests that a string longer than the buffers of the Reader is written in parts
+func TestReplacementStringShortDst(t *testing.T) {
+	marker := string(bytes.Repeat([]byte("?"), 8192))
+	utf8Reader := unutf16.NewReader(bytes.NewReader([]byte{0xFF, 0xFE, 0x00, 0xD8, 0x61, 0x00}), unutf16.WithReplacementString(marker))
+
+	output, err := io.ReadAll(utf8Reader)
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, marker+"a", string(output))
+}
+
+// TestReplacementStringConfigError tests that an invalid string and the combination with strict mode are rejected
+func TestReplacementStringConfigError(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []unutf16.Option
+	}{
+		{name: "invalid utf8", opts: []unutf16.Option{unutf16.WithReplacementString("\xFF")}},
+		{name: "strict", opts: []unutf16.Option{unutf16.WithReplacementString("[?]"), unutf16.WithStrict()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := io.ReadAll(unutf16.NewReader(bytes.NewReader([]byte("hi")), tt.opts...))
+
+			var configErr *unutf16.ConfigError
+			assert.True(t, errors.As(err, &configErr))
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package unutf16
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// ErrUnsupportedEncoding is returned when output is requested in an encoding
+// the Writer cannot produce, such as EncodingUnknown.
+var ErrUnsupportedEncoding = errors.New("unsupported encoding")
+
+// ErrWriterClosed is returned by a Writer that is used after Close was called.
+var ErrWriterClosed = errors.New("writer is closed")
+
+// WriterOption configures a Writer created by NewWriter.
+type WriterOption func(*writerConfig)
+
+// writerConfig holds the settings applied to a Writer through its options.
+// The zero value represents the default behavior of NewWriter.
+type writerConfig struct {
+	// encoding is the encoding of the produced output.
+	encoding Encoding
+	// omitBOM disables the BOM that is otherwise written ahead of the output.
+	omitBOM bool
+	// newlines is the style of the line endings in the output.
+	newlines NewlineStyle
+}
+
+// newWriterConfig applies the given options on top of the default configuration.
+func newWriterConfig(opts []WriterOption) writerConfig {
+	c := writerConfig{
+		encoding: EncodingUTF16LE,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithWriterEndianness selects the byte order of the UTF-16 output.
+// Without this option the Writer produces UTF-16 Little Endian, the form most Windows tools expect.
+func WithWriterEndianness(e unicode.Endianness) WriterOption {
+	return func(c *writerConfig) {
+		c.encoding = utf16Encoding(e)
+	}
+}
+
+// WithWriterEncoding selects the encoding of the output, which may be any UTF-16
+// or UTF-32 Encoding, or EncodingUTF8BOM for UTF-8 prefixed with a BOM, which some
+// consumers such as spreadsheet applications expect. Other encodings make every
+// write fail with ErrUnsupportedEncoding.
+func WithWriterEncoding(e Encoding) WriterOption {
+	return func(c *writerConfig) {
+		c.encoding = e
+	}
+}
+
+// WithoutBOM disables the Byte Order Mark (BOM) the Writer emits ahead of the first write.
+func WithoutBOM() WriterOption {
+	return func(c *writerConfig) {
+		c.omitBOM = true
+	}
+}
+
+// WithWriterNewlines makes the Writer convert line endings to the given style before
+// encoding, such as NewlineCRLF when producing files for Windows editors. A line ending
+// that is split across two Write calls is converted correctly. NewlineUnknown and other
+// values that name no line ending make every write fail with a *ConfigError.
+func WithWriterNewlines(style NewlineStyle) WriterOption {
+	return func(c *writerConfig) {
+		c.newlines = style
+	}
+}
+
+// NewWriter initializes a new Writer that wraps an existing io.Writer.
+// Everything written to the returned Writer is expected to be UTF-8 and is
+// converted to UTF-16 before being passed on to w. A BOM is emitted with the
+// first write unless WithoutBOM is given.
+func NewWriter(w io.Writer, opts ...WriterOption) *Writer {
+	c := newWriterConfig(opts)
+
+	writer := &Writer{
+		destination: w,
+	}
+	t := c.encoding.encoder(!c.omitBOM)
+	if t == nil {
+		writer.err = fmt.Errorf("cannot encode %v: %w", c.encoding, ErrUnsupportedEncoding)
+		return writer
+	}
+	if c.newlines < NewlineAsIs || c.newlines > NewlineCR {
+		writer.err = &ConfigError{Reason: fmt.Sprintf("newline style %d names no line ending", c.newlines)}
+		return writer
+	}
+	if n := c.newlines.transformer(); n != nil {
+		t = transform.Chain(n, t)
+	}
+	writer.encoder = transform.NewWriter(w, t)
+	return writer
+}
+
+// NewWriterMatching initializes a new Writer like NewWriter, whose output matches the
+// input of src in encoding and byte order, with a BOM if and only if src had one.
+// This keeps round trips through an editor faithful to the original file. Since src
+// detects its encoding lazily, it has to have been read from before. An error wrapping
+// ErrUnsupportedEncoding is returned if the encoding of src cannot be reproduced, such
+// as for EncodingFallback or while it is still EncodingUnknown.
+func NewWriterMatching(w io.Writer, src *Reader, opts ...WriterOption) (*Writer, error) {
+	encoding := src.DetectedEncoding()
+	if encoding == EncodingPassthrough {
+		// UTF-8 without a BOM is UTF-8 whose BOM is omitted
+		encoding = EncodingUTF8BOM
+	}
+	if encoding.encoder(false) == nil {
+		return nil, fmt.Errorf("cannot encode %v: %w", src.DetectedEncoding(), ErrUnsupportedEncoding)
+	}
+
+	// Never append to the caller's slice
+	opts = append(opts[:len(opts):len(opts)], WithWriterEncoding(encoding))
+	if src.BOMLength() == 0 {
+		opts = append(opts, WithoutBOM())
+	}
+	return NewWriter(w, opts...), nil
+}
+
+// EncodeBytes converts a UTF-8 payload to the given encoding, prefixed with its BOM,
+// using the transformer of e.NewEncoder. EncodingPassthrough returns a copy of b unchanged.
+// It returns an error wrapping ErrUnsupportedEncoding for EncodingUnknown, EncodingFallback
+// and values that name no encoding.
+func EncodeBytes(b []byte, e Encoding) ([]byte, error) {
+	encoded, _, err := transform.Bytes(e.NewEncoder(), b)
+	if err != nil {
+		return nil, err
+	}
+	return encoded, nil
+}
+
+// Writer is a custom io.Writer that wraps an existing io.Writer (destination)
+// and converts UTF-8 encoded data written to it into UTF-16.
+// The encoder field is an internal io.Writer that handles the UTF-8 to UTF-16 conversion.
+type Writer struct {
+	destination io.Writer // Underlying destination writer (UTF-16 encoded)
+	encoder     io.Writer // Encoder that will handle the conversion from UTF-8 to UTF-16
+	err         error     // Reason the options cannot be honored or the Writer is closed, reported by every write
+	scratch     []byte    // Buffer WriteString copies strings through
+}
+
+// Write implements the io.Writer interface.
+// It returns the number of UTF-8 bytes consumed from p. A rune that is split
+// across two Write calls is held back until its remaining bytes arrive.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.encoder.Write(p)
+}
+
+// writeStringChunk is the size of the chunks WriteString copies strings through.
+const writeStringChunk = 512
+
+// WriteString implements the io.StringWriter interface.
+// It writes s like Write does, copying it through a buffer that is reused across
+// calls instead of converting the whole string to a byte slice.
+func (w *Writer) WriteString(s string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.scratch == nil {
+		w.scratch = make([]byte, writeStringChunk)
+	}
+
+	var written int
+	for len(s) > 0 {
+		n := copy(w.scratch, s)
+		m, err := w.encoder.Write(w.scratch[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		s = s[n:]
+	}
+	return written, nil
+}
+
+// ReadFrom implements the io.ReaderFrom interface, which io.Copy uses unless the source
+// implements io.WriterTo. It reads UTF-8 from r until EOF and encodes it in bulk through
+// a single buffer, emitting the BOM ahead of the first chunk like Write does.
+// The returned count is the number of UTF-8 bytes consumed from r.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	var consumed int64
+	buf := make([]byte, copyBufferSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			m, writeErr := w.encoder.Write(buf[:n])
+			consumed += int64(m)
+			if writeErr != nil {
+				return consumed, writeErr
+			}
+		}
+		if err == io.EOF {
+			return consumed, nil
+		}
+		if err != nil {
+			return consumed, err
+		}
+	}
+}
+
+// Flush implements the Flush method of buffered writers such as bufio.Writer.
+// The encoded output of everything written so far is passed on to the destination
+// right away, so Flush only flushes the destination if it has a Flush method itself.
+// A rune that is split across Write calls is still held back until its remaining
+// bytes arrive, since it cannot be encoded before; only Close forces it out.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return flushDestination(w.destination)
+}
+
+// Close implements the io.Closer interface.
+// It ends the output: a rune that is still held back is encoded as U+FFFD, the
+// BOM is written if nothing else was, and the destination is flushed like Flush
+// does. The destination is closed as well if it implements io.Closer. Writes and
+// Close calls after the first Close return ErrWriterClosed.
+func (w *Writer) Close() error {
+	if w.err == ErrWriterClosed {
+		return w.err
+	}
+
+	// An encoder that could not be set up has nothing to end
+	var err error
+	if w.err == nil {
+		err = w.encoder.(io.Closer).Close()
+		if err == nil {
+			err = flushDestination(w.destination)
+		}
+	}
+	w.err = ErrWriterClosed
+
+	if closer, ok := w.destination.(io.Closer); ok {
+		closeErr := closer.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// flushDestination flushes w if it has a Flush method, such as a bufio.Writer.
+func flushDestination(w io.Writer) error {
+	if flusher, ok := w.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+package unutf16_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+
+	"github.com/nolotz/unutf16"
+)
+
+// TestWriterUTF16LE tests conversion of UTF-8 to UTF-16LE with the default BOM
+func TestWriterUTF16LE(t *testing.T) {
+	var output bytes.Buffer
+	utf16Writer := unutf16.NewWriter(&output)
+
+	n, err := utf16Writer.Write([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+
+	expected := []byte{0xFF, 0xFE, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F, 0x00}
+	assert.Equal(t, 5, n)
+	assert.Equal(t, expected, output.Bytes())
+}
+
+// TestWriterUTF16BEWithoutBOM tests conversion of UTF-8 to UTF-16BE without a BOM
+func TestWriterUTF16BEWithoutBOM(t *testing.T) {
+	var output bytes.Buffer
+	utf16Writer := unutf16.NewWriter(&output, unutf16.WithWriterEndianness(unicode.BigEndian), unutf16.WithoutBOM())
+
+	_, err := utf16Writer.Write([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+
+	expected := []byte{0x00, 0x68, 0x00, 0x65, 0x00, 0x6C, 0x00, 0x6C, 0x00, 0x6F}
+	assert.Equal(t, expected, output.Bytes())
+}
+
+// TestWriterRoundTrip tests that output of the Writer is decoded back to the original text by the Reader
+func TestWriterRoundTrip(t *testing.T) {
+	text := "héllo wörld 👋"
+
+	var encoded bytes.Buffer
+	_, err := unutf16.NewWriter(&encoded, unutf16.WithWriterEndianness(unicode.BigEndian)).Write([]byte(text))
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+
+	var decoded bytes.Buffer
+	_, err = decoded.ReadFrom(unutf16.NewReader(&encoded))
+	if err != nil {
+		t.Fatalf("Error reading from UTF8 reader: %v", err)
+	}
+
+	assert.Equal(t, text, decoded.String())
+}
+
+// TestWriterWriteString tests that WriteString encodes strings longer than its buffer
+func TestWriterWriteString(t *testing.T) {
+	// Long enough to be split into several chunks, possibly inside a rune
+	text := strings.Repeat("héllo 👋 ", 200)
+
+	var output bytes.Buffer
+	n, err := unutf16.NewWriter(&output).WriteString(text)
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+
+	decoded, err := unutf16.DecodeString(output.Bytes())
+	if err != nil {
+		t.Fatalf("Error decoding string: %v", err)
+	}
+
+	assert.Equal(t, len(text), n)
+	assert.Equal(t, text, decoded)
+}
+
+// TestWriterNewlines tests that line endings are converted to the configured style
+func TestWriterNewlines(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    unutf16.NewlineStyle
+		input    []string
+		expected string
+	}{
+		{name: "as is", style: unutf16.NewlineAsIs, input: []string{"a\nb\r\nc\r"}, expected: "a\nb\r\nc\r"},
+		{name: "lf", style: unutf16.NewlineLF, input: []string{"a\nb\r\nc\r"}, expected: "a\nb\nc\n"},
+		{name: "crlf", style: unutf16.NewlineCRLF, input: []string{"a\nb\r\nc\r"}, expected: "a\r\nb\r\nc\r"},
+		{name: "crlf split", style: unutf16.NewlineCRLF, input: []string{"a\r", "\nb\n", "\n"}, expected: "a\r\nb\r\n\r\n"},
+		{name: "lf split", style: unutf16.NewlineLF, input: []string{"a\r", "\nb\r", "c"}, expected: "a\nb\nc"},
+		{name: "cr", style: unutf16.NewlineCR, input: []string{"a\nb\r\nc\r"}, expected: "a\rb\rc\r"},
+		{name: "cr split", style: unutf16.NewlineCR, input: []string{"a\r", "\nb\n", "c"}, expected: "a\rb\rc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			utf16Writer := unutf16.NewWriter(&output, unutf16.WithWriterNewlines(tt.style))
+
+			for _, chunk := range tt.input {
+				n, err := utf16Writer.Write([]byte(chunk))
+				if err != nil {
+					t.Fatalf("Error writing to UTF16 writer: %v", err)
+				}
+				assert.Equal(t, len(chunk), n)
+			}
+
+			decoded, err := unutf16.DecodeString(output.Bytes())
+			if err != nil {
+				t.Fatalf("Error decoding string: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, decoded)
+		})
+	}
+}
+
+// TestWriterNewlinesConfigError tests that styles naming no line ending are rejected
+func TestWriterNewlinesConfigError(t *testing.T) {
+	for _, style := range []unutf16.NewlineStyle{unutf16.NewlineUnknown, unutf16.NewlineCR + 1} {
+		var output bytes.Buffer
+		_, err := unutf16.NewWriter(&output, unutf16.WithWriterNewlines(style)).Write([]byte("a\n"))
+
+		assert.IsType(t, new(unutf16.ConfigError), err)
+		assert.Empty(t, output.Bytes())
+	}
+}
+
+// TestWriterUTF8BOM tests that UTF-8 output is written unchanged after its BOM
+func TestWriterUTF8BOM(t *testing.T) {
+	var output bytes.Buffer
+	utf8Writer := unutf16.NewWriter(&output, unutf16.WithWriterEncoding(unutf16.EncodingUTF8BOM))
+
+	// A split rune passes through as well
+	for _, chunk := range []string{"h\xC3", "\xA9llo"} {
+		_, err := utf8Writer.Write([]byte(chunk))
+		if err != nil {
+			t.Fatalf("Error writing to UTF8 writer: %v", err)
+		}
+	}
+
+	assert.Equal(t, "\xEF\xBB\xBFhéllo", output.String())
+}
+
+// TestWriterMatching tests that the Writer reproduces the encoding and BOM of the Reader's input
+func TestWriterMatching(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		opts  []unutf16.Option
+	}{
+		{name: "utf16le", input: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}},
+		{name: "utf16be", input: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}},
+		{name: "utf16le without bom", input: []byte{0x68, 0x00, 0x69, 0x00}, opts: []unutf16.Option{unutf16.WithDefaultEndianness(unicode.LittleEndian)}},
+		{name: "utf32be", input: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69}},
+		{name: "utf8 with bom", input: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}},
+		{name: "utf8", input: []byte("hi")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf8Reader := unutf16.NewReader(bytes.NewReader(tt.input), tt.opts...)
+			decoded, err := io.ReadAll(utf8Reader)
+			if err != nil {
+				t.Fatalf("Error reading from UTF8 reader: %v", err)
+			}
+
+			var output bytes.Buffer
+			writer, err := unutf16.NewWriterMatching(&output, utf8Reader)
+			if err != nil {
+				t.Fatalf("Error creating matching writer: %v", err)
+			}
+			_, err = writer.Write(decoded)
+			if err != nil {
+				t.Fatalf("Error writing to UTF16 writer: %v", err)
+			}
+
+			assert.Equal(t, tt.input, output.Bytes())
+		})
+	}
+}
+
+// TestWriterMatchingUnsupported tests that a Reader whose encoding cannot be reproduced is rejected
+func TestWriterMatchingUnsupported(t *testing.T) {
+	// Nothing has been read yet, so the encoding is unknown
+	writer, err := unutf16.NewWriterMatching(io.Discard, unutf16.NewReader(bytes.NewReader(nil)))
+
+	assert.ErrorIs(t, err, unutf16.ErrUnsupportedEncoding)
+	assert.EqualError(t, err, "cannot encode unknown: unsupported encoding")
+	assert.Nil(t, writer)
+}
+
+// TestWriterReadFrom tests that io.Copy encodes a UTF-8 source in bulk with a single BOM
+func TestWriterReadFrom(t *testing.T) {
+	text := strings.Repeat("héllo 👋 ", 10000)
+
+	var output bytes.Buffer
+	utf16Writer := unutf16.NewWriter(&output, unutf16.WithWriterEndianness(unicode.BigEndian))
+
+	// Hide the WriteTo method of the source so io.Copy uses ReadFrom
+	n, err := io.Copy(utf16Writer, struct{ io.Reader }{strings.NewReader(text)})
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+
+	decoded, err := unutf16.DecodeString(output.Bytes())
+	if err != nil {
+		t.Fatalf("Error decoding string: %v", err)
+	}
+
+	assert.Equal(t, int64(len(text)), n)
+	assert.Equal(t, []byte{0xFE, 0xFF}, output.Bytes()[:2])
+	assert.Equal(t, text, decoded)
+}
+
+// TestWriterReadFromFailure tests that read failures of the source are reported
+func TestWriterReadFromFailure(t *testing.T) {
+	var output bytes.Buffer
+	utf16Writer := unutf16.NewWriter(&output)
+
+	n, err := utf16Writer.ReadFrom(io.MultiReader(strings.NewReader("hi"), new(errorReader)))
+
+	assert.ErrorIs(t, err, simulatedError)
+	assert.Equal(t, int64(2), n)
+}
+
+// TestEncodeBytes tests one-shot encoding of UTF-8 payloads
+func TestEncodeBytes(t *testing.T) {
+	tests := []struct {
+		encoding unutf16.Encoding
+		expected []byte
+	}{
+		{encoding: unutf16.EncodingUTF16LE, expected: []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}},
+		{encoding: unutf16.EncodingUTF16BE, expected: []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}},
+		{encoding: unutf16.EncodingUTF32LE, expected: []byte{0xFF, 0xFE, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69, 0x00, 0x00, 0x00}},
+		{encoding: unutf16.EncodingUTF32BE, expected: []byte{0x00, 0x00, 0xFE, 0xFF, 0x00, 0x00, 0x00, 0x68, 0x00, 0x00, 0x00, 0x69}},
+		{encoding: unutf16.EncodingUTF8BOM, expected: []byte{0xEF, 0xBB, 0xBF, 0x68, 0x69}},
+		{encoding: unutf16.EncodingPassthrough, expected: []byte("hi")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding.String(), func(t *testing.T) {
+			output, err := unutf16.EncodeBytes([]byte("hi"), tt.encoding)
+			if err != nil {
+				t.Fatalf("Error encoding bytes: %v", err)
+			}
+
+			// EncodeBytes agrees with the transformer of NewEncoder
+			transformed, _, err := transform.Bytes(tt.encoding.NewEncoder(), []byte("hi"))
+			if err != nil {
+				t.Fatalf("Error encoding bytes: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, output)
+			assert.Equal(t, tt.expected, transformed)
+		})
+	}
+}
+
+// TestEncodeBytesUnsupported tests that encodings the Writer cannot produce are rejected
+func TestEncodeBytesUnsupported(t *testing.T) {
+	for _, encoding := range []unutf16.Encoding{unutf16.EncodingUnknown, unutf16.EncodingFallback} {
+		_, err := unutf16.EncodeBytes([]byte("hi"), encoding)
+		assert.ErrorIs(t, err, unutf16.ErrUnsupportedEncoding)
+
+		_, err = unutf16.EncodeBytes(nil, encoding)
+		assert.ErrorIs(t, err, unutf16.ErrUnsupportedEncoding)
+	}
+
+	_, err := unutf16.NewWriter(io.Discard, unutf16.WithWriterEncoding(unutf16.EncodingUnknown)).Write([]byte("hi"))
+	assert.ErrorIs(t, err, unutf16.ErrUnsupportedEncoding)
+	assert.EqualError(t, err, "cannot encode unknown: unsupported encoding")
+}
+
+// TestWriterClose tests that Close writes out a rune split across Write calls and closes the destination
+func TestWriterClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []byte
+	}{
+		// "é" is 0xC3 0xA9 in UTF-8
+		{name: "split rune", input: []string{"h\xC3", "\xA9"}, expected: []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}},
+		// "👋" is 0xF0 0x9F 0x91 0x8B in UTF-8 and a surrogate pair in UTF-16
+		{name: "split surrogate pair", input: []string{"\xF0\x9F", "\x91", "\x8B"}, expected: []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x4B, 0xDC}},
+		{name: "incomplete rune", input: []string{"h\xC3"}, expected: []byte{0xFF, 0xFE, 0x68, 0x00, 0xFD, 0xFF}},
+		{name: "nothing written", expected: []byte{0xFF, 0xFE}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination := &writeCloseRecorder{}
+			utf16Writer := unutf16.NewWriter(destination)
+
+			for _, chunk := range tt.input {
+				_, err := utf16Writer.Write([]byte(chunk))
+				if err != nil {
+					t.Fatalf("Error writing to UTF16 writer: %v", err)
+				}
+			}
+
+			err := utf16Writer.Close()
+			if err != nil {
+				t.Fatalf("Error closing UTF16 writer: %v", err)
+			}
+
+			assert.Equal(t, tt.expected, destination.Bytes())
+			assert.True(t, destination.closed)
+		})
+	}
+}
+
+// TestWriterCloseTwice tests that a closed Writer can no longer be used
+func TestWriterCloseTwice(t *testing.T) {
+	destination := &writeCloseRecorder{}
+	utf16Writer := unutf16.NewWriter(destination)
+
+	err := utf16Writer.Close()
+	if err != nil {
+		t.Fatalf("Error closing UTF16 writer: %v", err)
+	}
+
+	_, err = utf16Writer.Write([]byte("hi"))
+	assert.ErrorIs(t, err, unutf16.ErrWriterClosed)
+	assert.ErrorIs(t, utf16Writer.Flush(), unutf16.ErrWriterClosed)
+	assert.ErrorIs(t, utf16Writer.Close(), unutf16.ErrWriterClosed)
+	assert.Equal(t, []byte{0xFF, 0xFE}, destination.Bytes())
+}
+
+// TestWriterCloseUnsupported tests that Close still closes the destination of a Writer that cannot encode
+func TestWriterCloseUnsupported(t *testing.T) {
+	destination := &writeCloseRecorder{}
+	utf16Writer := unutf16.NewWriter(destination, unutf16.WithWriterEncoding(unutf16.EncodingUnknown))
+
+	assert.NoError(t, utf16Writer.Close())
+	assert.True(t, destination.closed)
+}
+
+// TestWriterFlush tests that Flush flushes a buffered destination, holding back an incomplete rune
+func TestWriterFlush(t *testing.T) {
+	var output bytes.Buffer
+	buffered := bufio.NewWriter(&output)
+	utf16Writer := unutf16.NewWriter(buffered)
+
+	_, err := utf16Writer.Write([]byte("h\xC3"))
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+	assert.Empty(t, output.Bytes())
+
+	err = utf16Writer.Flush()
+	if err != nil {
+		t.Fatalf("Error flushing UTF16 writer: %v", err)
+	}
+	assert.Equal(t, []byte{0xFF, 0xFE, 0x68, 0x00}, output.Bytes())
+
+	_, err = utf16Writer.Write([]byte("\xA9"))
+	if err != nil {
+		t.Fatalf("Error writing to UTF16 writer: %v", err)
+	}
+	err = utf16Writer.Close()
+	if err != nil {
+		t.Fatalf("Error closing UTF16 writer: %v", err)
+	}
+	assert.Equal(t, []byte{0xFF, 0xFE, 0x68, 0x00, 0xE9, 0x00}, output.Bytes())
+}
+
+// FuzzRoundTrip tests that valid UTF-8 written by the Writer is read back unchanged by the Reader.
+func FuzzRoundTrip(f *testing.F) {
+	f.Add("")
+	f.Add("hello")
+	f.Add("héllo wörld 👋")
+	f.Add("\uFEFF")
+	f.Add("a\r\nb")
+
+	encodings := []unutf16.Encoding{
+		unutf16.EncodingUTF16LE,
+		unutf16.EncodingUTF16BE,
+		unutf16.EncodingUTF32LE,
+		unutf16.EncodingUTF32BE,
+		unutf16.EncodingUTF8BOM,
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		if !utf8.ValidString(text) {
+			t.Skip()
+		}
+
+		for _, encoding := range encodings {
+			// The UTF-16LE BOM followed by a NUL character reads as the UTF-32LE BOM
+			if encoding == unutf16.EncodingUTF16LE && strings.HasPrefix(text, "\x00") {
+				continue
+			}
+
+			encoded, err := unutf16.EncodeBytes([]byte(text), encoding)
+			if err != nil {
+				t.Fatalf("Error encoding bytes: %v", err)
+			}
+
+			decoded, err := unutf16.DecodeString(encoded)
+			if err != nil {
+				t.Fatalf("Error decoding string: %v", err)
+			}
+
+			assert.Equal(t, text, decoded, "encoding %v", encoding)
+		}
+	})
+}
+
+type writeCloseRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *writeCloseRecorder) Close() error {
+	w.closed = true
+	return nil
+}